@@ -0,0 +1,309 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+// DefaultRegistry is the credentials key the Docker CLI uses for Docker Hub.
+const DefaultRegistry = "https://index.docker.io/v1/"
+
+// Username returned by credential helpers for identity tokens.
+const tokenUsername = "<token>"
+
+// dockerConfigFile holds the parts of the Docker CLI's config.json used to
+// find registry credentials.
+type dockerConfigFile struct {
+	Auths       map[string]types.AuthConfig `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+// helperCredentials is the response of a docker-credential-* helper.
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// registryHostname strips the scheme and path from a registry address, so
+// that "https://registry.example.com/v2/" becomes "registry.example.com".
+func registryHostname(address string) string {
+	address = strings.TrimPrefix(address, "http://")
+	address = strings.TrimPrefix(address, "https://")
+	return strings.SplitN(address, "/", 2)[0]
+}
+
+// registryKey returns the key credentials for address are stored under.
+func registryKey(address string) string {
+	switch registryHostname(address) {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+		return DefaultRegistry
+	}
+	return registryHostname(address)
+}
+
+// imageRegistry returns the registry key for an image reference.
+func imageRegistry(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return DefaultRegistry
+	}
+	return registryKey(reference.Domain(named))
+}
+
+// searchRegistry returns the registry key for an image search term, which
+// names a registry only if its first component looks like a hostname.
+func searchRegistry(term string) string {
+	parts := strings.SplitN(term, "/", 2)
+
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return registryKey(parts[0])
+	}
+	return DefaultRegistry
+}
+
+// dockerConfigPath returns the location of the Docker CLI's config.json,
+// honoring the DOCKER_CONFIG environment variable.
+func dockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// loadDockerConfig reads the Docker CLI's config.json. A missing file is
+// treated as an empty config.
+func loadDockerConfig() (dockerConfigFile, error) {
+	var config dockerConfigFile
+
+	data, err := ioutil.ReadFile(dockerConfigPath())
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return config, fmt.Errorf("failed to read docker config: %s", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse docker config: %s", err)
+	}
+	return config, nil
+}
+
+// helperAuth asks the docker-credential-<helper> program for the
+// credentials stored for key. It returns an empty AuthConfig if the helper
+// has none.
+func helperAuth(helper, key string) (types.AuthConfig, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(key)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+
+		if strings.Contains(output, "credentials not found") {
+			return types.AuthConfig{}, nil
+		}
+		return types.AuthConfig{}, fmt.Errorf(
+			"failed to run credential helper %s: %s: %s", helper, err, output)
+	}
+
+	var creds helperCredentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return types.AuthConfig{}, fmt.Errorf(
+			"failed to parse credential helper %s output: %s", helper, err)
+	}
+
+	auth := types.AuthConfig{ServerAddress: key, Username: creds.Username}
+	if creds.Username == tokenUsername {
+		auth.Username, auth.IdentityToken = "", creds.Secret
+	} else {
+		auth.Password = creds.Secret
+	}
+	return auth, nil
+}
+
+// fileAuth returns the credentials stored directly in config for key,
+// decoding the base64 "auth" field into a username and password. If several
+// addresses normalize to key, an exact match wins, then the first address
+// in sorted order.
+func fileAuth(config dockerConfigFile, key string) (types.AuthConfig, error) {
+	var addresses []string
+	for address := range config.Auths {
+		if registryKey(address) == key {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if (addresses[i] == key) != (addresses[j] == key) {
+			return addresses[i] == key
+		}
+		return addresses[i] < addresses[j]
+	})
+
+	for _, address := range addresses {
+		auth := config.Auths[address]
+
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return types.AuthConfig{}, fmt.Errorf("failed to decode auth for %s: %s", key, err)
+			}
+
+			userPass := strings.SplitN(string(decoded), ":", 2)
+			if len(userPass) != 2 {
+				return types.AuthConfig{}, fmt.Errorf("invalid auth for %s", key)
+			}
+			auth.Username, auth.Password, auth.Auth = userPass[0], userPass[1], ""
+		}
+
+		auth.ServerAddress = key
+		return auth, nil
+	}
+	return types.AuthConfig{}, nil
+}
+
+// credHelper returns the credential helper configured for key. Helpers are
+// looked up by the full key first, as the Docker CLI stores Docker Hub's
+// under "https://index.docker.io/v1/", then by the key's hostname.
+func credHelper(config dockerConfigFile, key string) (string, bool) {
+	if helper, ok := config.CredHelpers[key]; ok {
+		return helper, true
+	}
+	helper, ok := config.CredHelpers[registryHostname(key)]
+	return helper, ok
+}
+
+// SetAuth stores explicit credentials for a registry. They take precedence
+// over anything in the Docker CLI's config. registry may be a hostname or
+// URL; an empty registry means Docker Hub.
+func (di *DockerInterface) SetAuth(registry string, auth types.AuthConfig) {
+	if di.auths == nil {
+		di.auths = make(map[string]types.AuthConfig)
+	}
+
+	auth.ServerAddress = registryKey(registry)
+	di.auths[auth.ServerAddress] = auth
+}
+
+// ResolveAuth returns the credentials for a registry, looking in the same
+// places as the Docker CLI: credentials set with SetAuth or Login, then the
+// registry's credHelpers entry, then credsStore, then the auths section of
+// config.json. An empty AuthConfig means no credentials were found.
+func (di *DockerInterface) ResolveAuth(registry string) (types.AuthConfig, error) {
+	key := registryKey(registry)
+
+	if auth, ok := di.auths[key]; ok {
+		return auth, nil
+	}
+
+	config, err := loadDockerConfig()
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+
+	if helper, ok := credHelper(config, key); ok {
+		return helperAuth(helper, key)
+	} else if config.CredsStore != "" {
+		return helperAuth(config.CredsStore, key)
+	}
+	return fileAuth(config, key)
+}
+
+// allAuths returns the credentials for every registry known from SetAuth,
+// Login, and the auths and credHelpers sections of config.json, keyed as
+// the daemon expects in a build request. Each registry is resolved as by
+// ResolveAuth. Registries stored only in credsStore are found through the
+// empty auths entry the Docker CLI writes for them on login.
+func (di *DockerInterface) allAuths() (map[string]types.AuthConfig, error) {
+	config, err := loadDockerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for key := range di.auths {
+		addresses = append(addresses, key)
+	}
+	for address := range config.Auths {
+		addresses = append(addresses, address)
+	}
+	for address := range config.CredHelpers {
+		addresses = append(addresses, address)
+	}
+
+	auths := make(map[string]types.AuthConfig)
+	for _, address := range addresses {
+		key := registryKey(address)
+		if _, ok := auths[key]; ok {
+			continue
+		}
+
+		auth, err := di.ResolveAuth(key)
+		if err != nil {
+			return nil, err
+		}
+		if auth != (types.AuthConfig{}) {
+			auths[key] = auth
+		}
+	}
+	return auths, nil
+}
+
+// encodeAuth returns auth in the form expected by the X-Registry-Auth header.
+func encodeAuth(auth types.AuthConfig) (string, error) {
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// registryAuth returns the encoded credentials for registry, or an empty
+// string to send requests anonymously if no credentials are stored. A
+// failing credential helper or unreadable config is returned as an error.
+func (di *DockerInterface) registryAuth(registry string) (string, error) {
+	auth, err := di.ResolveAuth(registry)
+	if err != nil {
+		return "", err
+	}
+	if auth == (types.AuthConfig{}) {
+		return "", nil
+	}
+	return encodeAuth(auth)
+}
+
+// Login validates auth against its registry and stores the credentials for
+// later pulls, pushes, and searches. If the registry returns an identity
+// token it is stored in place of the password. Login returns the status
+// message reported by the registry.
+func (di *DockerInterface) Login(ctx context.Context, auth types.AuthConfig) (string, error) {
+	auth.ServerAddress = registryKey(auth.ServerAddress)
+
+	response, err := di.Client.RegistryLogin(ctx, auth)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in: %s", err)
+	}
+
+	if response.IdentityToken != "" {
+		auth.Password, auth.IdentityToken = "", response.IdentityToken
+	}
+	di.SetAuth(auth.ServerAddress, auth)
+	return response.Status, nil
+}
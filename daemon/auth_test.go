@@ -0,0 +1,196 @@
+package daemon
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Config with inline credentials for one registry and a helper for another.
+const testDockerConfig = `{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "aHViOnNlY3JldA=="},
+		"registry.example.com": {"identitytoken": "abc"}
+	},
+	"credHelpers": {"helper.example.com": "dockland-test"}
+}`
+
+// Credential helper that returns a fixed identity token.
+const testCredentialHelper = `#!/bin/sh
+echo '{"ServerURL":"helper.example.com","Username":"<token>","Secret":"xyz"}'
+`
+
+// Point DOCKER_CONFIG and PATH at a temporary config and credential helper.
+func setupDockerConfig() func() {
+	dir, _ := ioutil.TempDir("", "dockland")
+	ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(testDockerConfig), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "docker-credential-dockland-test"),
+		[]byte(testCredentialHelper), 0755)
+
+	oldConfig, oldPath := os.Getenv("DOCKER_CONFIG"), os.Getenv("PATH")
+	os.Setenv("DOCKER_CONFIG", dir)
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+oldPath)
+
+	return func() {
+		os.Setenv("DOCKER_CONFIG", oldConfig)
+		os.Setenv("PATH", oldPath)
+		os.RemoveAll(dir)
+	}
+}
+
+// TestResolveAuth
+func TestResolveAuth(t *testing.T) {
+	defer setupDockerConfig()()
+	di := &DockerInterface{}
+
+	tables := []struct {
+		registry string
+		want     types.AuthConfig
+	}{
+		{imageRegistry("nginx"), types.AuthConfig{
+			Username: "hub", Password: "secret", ServerAddress: DefaultRegistry}},
+		{imageRegistry("registry.example.com/team/app:1.0"), types.AuthConfig{
+			IdentityToken: "abc", ServerAddress: "registry.example.com"}},
+		{"https://helper.example.com/v2/", types.AuthConfig{
+			IdentityToken: "xyz", ServerAddress: "helper.example.com"}},
+		{"unknown.example.com", types.AuthConfig{}},
+	}
+
+	for _, table := range tables {
+		got, err := di.ResolveAuth(table.registry)
+		if err != nil {
+			t.Errorf("got error resolving auth for %s: %s", table.registry, err)
+		} else if got != table.want {
+			t.Errorf("got auth %+v for %s, want %+v", got, table.registry, table.want)
+		}
+	}
+
+	di.SetAuth("docker.io", types.AuthConfig{Username: "explicit", Password: "pw"})
+	if got, _ := di.ResolveAuth(""); got.Username != "explicit" {
+		t.Errorf("got username %s, want explicit credentials", got.Username)
+	}
+}
+
+// TestCredHelper
+func TestCredHelper(t *testing.T) {
+	config := dockerConfigFile{CredHelpers: map[string]string{
+		DefaultRegistry:       "hub",
+		"index.docker.io":     "hostname",
+		"helper.example.com":  "example",
+		"https://other.test/": "unused",
+	}}
+
+	tables := map[string]string{
+		DefaultRegistry:      "hub",
+		"helper.example.com": "example",
+		"other.test":         "",
+	}
+
+	for key, want := range tables {
+		if got, _ := credHelper(config, key); got != want {
+			t.Errorf("got helper %q for %s, want %q", got, key, want)
+		}
+	}
+}
+
+// TestAllAuths
+func TestAllAuths(t *testing.T) {
+	defer setupDockerConfig()()
+	di := &DockerInterface{}
+	di.SetAuth("registry.example.com", types.AuthConfig{Username: "explicit"})
+
+	auths, err := di.allAuths()
+	if err != nil {
+		t.Logf("got error collecting auths: %s", err)
+		t.FailNow()
+	}
+
+	want := map[string]string{
+		DefaultRegistry:        "hub",
+		"registry.example.com": "explicit",
+		"helper.example.com":   "",
+	}
+	if len(auths) != len(want) {
+		t.Errorf("got auths %+v, want %d registries", auths, len(want))
+	}
+	for key, username := range want {
+		if auth, ok := auths[key]; !ok || auth.Username != username {
+			t.Errorf("got auth %+v for %s, want username %q", auth, key, username)
+		}
+	}
+	if auths["helper.example.com"].IdentityToken != "xyz" {
+		t.Errorf("got no helper credentials for helper.example.com")
+	}
+}
+
+// TestFileAuthDuplicates
+func TestFileAuthDuplicates(t *testing.T) {
+	config := dockerConfigFile{Auths: map[string]types.AuthConfig{
+		"https://registry.example.com/v2/": {Username: "v2"},
+		"registry.example.com":             {Username: "exact"},
+		"http://registry.example.com":      {Username: "http"},
+	}}
+
+	for i := 0; i < 10; i++ {
+		if got, _ := fileAuth(config, "registry.example.com"); got.Username != "exact" {
+			t.Fatalf("got username %s, want exact match", got.Username)
+		}
+	}
+
+	delete(config.Auths, "registry.example.com")
+	for i := 0; i < 10; i++ {
+		if got, _ := fileAuth(config, "registry.example.com"); got.Username != "http" {
+			t.Fatalf("got username %s, want first sorted address", got.Username)
+		}
+	}
+}
+
+// TestRegistryAuthError
+func TestRegistryAuthError(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dockland")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte("{not json"), 0600)
+
+	oldConfig := os.Getenv("DOCKER_CONFIG")
+	os.Setenv("DOCKER_CONFIG", dir)
+	defer os.Setenv("DOCKER_CONFIG", oldConfig)
+
+	di := &DockerInterface{}
+	if _, err := di.registryAuth(DefaultRegistry); err == nil {
+		t.Error("expected error from invalid docker config")
+	}
+	if err := di.PullImage(context.TODO(), "nginx"); err == nil {
+		t.Error("expected pull to fail on invalid docker config")
+	}
+}
+
+// TestSearchRegistry
+func TestSearchRegistry(t *testing.T) {
+	tables := map[string]string{
+		"nginx":                      DefaultRegistry,
+		"library/nginx":              DefaultRegistry,
+		"registry.example.com/nginx": "registry.example.com",
+		"localhost:5000/nginx":       "localhost:5000",
+	}
+
+	for term, want := range tables {
+		if got := searchRegistry(term); got != want {
+			t.Errorf("got registry %s for %s, want %s", got, term, want)
+		}
+	}
+}
+
+// TestLogin
+func TestLogin(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	if _, err := di.Login(ctx, types.AuthConfig{
+		Username: "dockland-no-such-user", Password: "wrong"}); err == nil {
+		t.Error("expected error logging in with bad credentials")
+	}
+}
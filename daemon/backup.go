@@ -0,0 +1,162 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/archive"
+)
+
+// DefaultHelperImage is the image used for the short-lived containers that
+// mount volumes for backup, restore and clone.
+const DefaultHelperImage = "alpine:latest"
+
+// volumeHelperPath is where helper containers mount the volume.
+const volumeHelperPath = "/volume"
+
+// VolumeBackupOptions controls how a volume is written to an archive.
+type VolumeBackupOptions struct {
+	// Gzip compresses the archive.
+	Gzip bool
+	// HelperImage overrides DefaultHelperImage.
+	HelperImage string
+	// Progress, if set, is called with the total number of bytes copied
+	// so far, before compression.
+	Progress func(bytes int64)
+}
+
+// VolumeRestoreOptions controls how an archive is written to a volume.
+type VolumeRestoreOptions struct {
+	// Clear removes the volume's contents before restoring. Otherwise the
+	// archive is merged into the volume, replacing files that exist in both.
+	Clear bool
+	// HelperImage overrides DefaultHelperImage.
+	HelperImage string
+	// Progress, if set, is called with the total number of bytes read so
+	// far from the archive.
+	Progress func(bytes int64)
+}
+
+// newVolumeHelper creates a container from image that mounts volumes, in
+// NewContainer's "volumes" format, and runs cmd when started. The image is
+// pulled if it is not present. The helper carries the interface's scope
+// labels but is not added to the cached container list.
+func (di *DockerInterface) newVolumeHelper(ctx context.Context,
+	image string, volumes string, cmd string) (string, error) {
+	if image == "" {
+		image = DefaultHelperImage
+	}
+
+	config, err := newContainerCreateConfig(map[string]string{
+		"image":   image,
+		"volumes": volumes,
+		"cmd":     cmd,
+	})
+	if err != nil {
+		return "", err
+	}
+	config.Config.Labels = di.scopeLabels()
+
+	response, err := di.Client.ContainerCreate(ctx, config.Config,
+		config.HostConfig, nil, nil, "")
+	if client.IsErrNotFound(err) {
+		if err := di.PullImage(ctx, image); err != nil {
+			return "", err
+		}
+		response, err = di.Client.ContainerCreate(ctx, config.Config,
+			config.HostConfig, nil, nil, "")
+	}
+	if err != nil {
+		return "", err
+	}
+	return response.ID, nil
+}
+
+// removeVolumeHelper removes a helper container. It does not use the
+// caller's context, so the helper is removed even if that was cancelled.
+func (di *DockerInterface) removeVolumeHelper(id string) {
+	di.removeContainer(context.Background(), id)
+}
+
+// runVolumeHelper starts a helper container and waits for it to exit,
+// returning an error if it exits with a non-zero status.
+func (di *DockerInterface) runVolumeHelper(ctx context.Context, id string) error {
+	if err := di.startContainer(ctx, id); err != nil {
+		return err
+	}
+
+	exit, err := di.WaitContainer(ctx, id, container.WaitConditionNotRunning)
+	if err != nil {
+		return err
+	}
+	if exit.StatusCode != 0 {
+		return fmt.Errorf("helper container exited with status %d", exit.StatusCode)
+	}
+	return nil
+}
+
+// BackupVolume writes the contents of a volume to w as a tar archive with
+// paths relative to the volume's root. It works with any volume driver, as
+// the data is read through a helper container that mounts the volume.
+func (di *DockerInterface) BackupVolume(ctx context.Context,
+	name string, w io.Writer, opts VolumeBackupOptions) error {
+	if _, err := di.Client.VolumeInspect(ctx, name); err != nil {
+		return fmt.Errorf("failed to back up volume: %s", err)
+	}
+
+	id, err := di.newVolumeHelper(ctx, opts.HelperImage,
+		name+":"+volumeHelperPath+":ro", "true")
+	if err != nil {
+		return fmt.Errorf("failed to back up volume: %s", err)
+	}
+	defer di.removeVolumeHelper(id)
+
+	content, _, err := di.Client.CopyFromContainer(ctx, id, volumeHelperPath)
+	if err != nil {
+		return fmt.Errorf("failed to back up volume: %s", err)
+	}
+	defer content.Close()
+
+	rebased := archive.RebaseArchiveEntries(content, path.Base(volumeHelperPath), ".")
+	defer rebased.Close()
+
+	if err := writeTarball(w, rebased, TarballOptions{opts.Gzip, opts.Progress}); err != nil {
+		return fmt.Errorf("failed to back up volume: %s", err)
+	}
+	return nil
+}
+
+// RestoreVolume extracts the tar archive read from r, optionally gzip
+// compressed, into a volume. The volume is created if it does not exist.
+func (di *DockerInterface) RestoreVolume(ctx context.Context,
+	name string, r io.Reader, opts VolumeRestoreOptions) error {
+	cmd := "true"
+	if opts.Clear {
+		cmd = "find," + volumeHelperPath + ",-mindepth,1,-delete"
+	}
+
+	id, err := di.newVolumeHelper(ctx, opts.HelperImage,
+		name+":"+volumeHelperPath, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to restore volume: %s", err)
+	}
+	defer di.removeVolumeHelper(id)
+
+	if opts.Clear {
+		if err := di.runVolumeHelper(ctx, id); err != nil {
+			return fmt.Errorf("failed to restore volume: %s", err)
+		}
+	}
+
+	content := &progressReader{reader: r, progress: opts.Progress}
+	if err := di.Client.CopyToContainer(ctx, id, volumeHelperPath, content,
+		types.CopyToContainerOptions{CopyUIDGID: true}); err != nil {
+		return fmt.Errorf("failed to restore volume: %s", err)
+	}
+	return di.RefreshVolumes(ctx)
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+// Build an in-memory tar archive holding the given files.
+func newTestArchive(files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return &buf
+}
+
+// List the regular files in a tar archive, which may be compressed.
+func readTestArchive(r io.Reader) (map[string]string, error) {
+	files := make(map[string]string)
+
+	content, err := archive.DecompressStream(r)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	tr := tar.NewReader(content)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			var data bytes.Buffer
+			io.Copy(&data, tr)
+			files[hdr.Name] = data.String()
+		}
+	}
+}
+
+// TestBackupRestoreVolume
+func TestBackupRestoreVolume(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	name, _ := di.NewVolume(ctx, map[string]string{"name": "backup_volume"})
+	defer di.RemoveVolume(ctx, name)
+
+	restore := newTestArchive(map[string]string{"a.conf": "a", "data/b.db": "b"})
+	if err := di.RestoreVolume(ctx, name, restore, VolumeRestoreOptions{}); err != nil {
+		t.Logf("got error restoring volume: %s", err)
+		t.FailNow()
+	}
+
+	var backup bytes.Buffer
+	if err := di.BackupVolume(ctx, name, &backup, VolumeBackupOptions{Gzip: true}); err != nil {
+		t.Logf("got error backing up volume: %s", err)
+		t.FailNow()
+	}
+
+	files, err := readTestArchive(&backup)
+	if err != nil {
+		t.Logf("got error reading backup: %s", err)
+		t.FailNow()
+	}
+	if files["./a.conf"] != "a" || files["./data/b.db"] != "b" {
+		t.Errorf("got backup files %v", files)
+	}
+
+	if err := di.BackupVolume(ctx, "no_such_volume", &backup, VolumeBackupOptions{}); err == nil {
+		t.Error("expected error backing up missing volume")
+	}
+}
+
+// TestRestoreVolumeClear
+func TestRestoreVolumeClear(t *testing.T) {
+	tables := []struct {
+		clear bool
+		want  int
+	}{
+		{false, 2},
+		{true, 1},
+	}
+
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	for _, table := range tables {
+		name, _ := di.NewVolume(ctx, map[string]string{"name": "restore_volume"})
+
+		di.RestoreVolume(ctx, name, newTestArchive(map[string]string{"old": "1"}),
+			VolumeRestoreOptions{})
+
+		err := di.RestoreVolume(ctx, name, newTestArchive(map[string]string{"new": "2"}),
+			VolumeRestoreOptions{Clear: table.clear})
+		if err != nil {
+			t.Logf("got error restoring volume: %s", err)
+			t.FailNow()
+		}
+
+		var backup bytes.Buffer
+		di.BackupVolume(ctx, name, &backup, VolumeBackupOptions{})
+		files, _ := readTestArchive(&backup)
+
+		if len(files) != table.want {
+			t.Errorf("got files %v with clear %t, want %d files", files, table.clear, table.want)
+		}
+		di.RemoveVolume(ctx, name)
+	}
+}
@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+)
+
+// BuildOptions controls how an image is built.
+type BuildOptions struct {
+	// ContextDir is the directory sent to the daemon as the build context.
+	ContextDir string
+	// Dockerfile is the path to the Dockerfile, relative to ContextDir or
+	// absolute. It may lie outside the context. Defaults to "Dockerfile".
+	Dockerfile string
+	BuildArgs  map[string]string
+	Labels     map[string]string
+	Tags       []string
+	// Target selects the stage to build in a multi-stage Dockerfile.
+	Target   string
+	Platform string
+	NoCache  bool
+	// Pull always attempts to pull newer versions of base images.
+	Pull bool
+	// Output, if set, is called with each line of build output.
+	Output func(line string)
+}
+
+// buildResult is the auxiliary message sent by the daemon once an image
+// has been built.
+type buildResult struct {
+	ID string
+}
+
+// readDockerignore returns the exclude patterns in contextDir's
+// .dockerignore, cleaned the same way as the Docker CLI. A missing file
+// excludes nothing.
+func readDockerignore(contextDir string) ([]string, error) {
+	var excludes []string
+
+	f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		invert := strings.HasPrefix(pattern, "!")
+		if invert {
+			pattern = strings.TrimSpace(pattern[1:])
+		}
+
+		if pattern != "" {
+			pattern = filepath.Clean(pattern)
+			pattern = filepath.ToSlash(pattern)
+			if len(pattern) > 1 && pattern[0] == '/' {
+				pattern = pattern[1:]
+			}
+		}
+
+		if invert {
+			pattern = "!" + pattern
+		}
+		excludes = append(excludes, pattern)
+	}
+	return excludes, scanner.Err()
+}
+
+// buildContext tars opts.ContextDir, honoring .dockerignore, and returns
+// the archive along with the Dockerfile's path inside it. A Dockerfile
+// outside the context is added to the archive under a generated name.
+func buildContext(opts BuildOptions) (io.ReadCloser, string, error) {
+	contextDir, err := filepath.Abs(opts.ContextDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dockerfile := opts.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(contextDir, dockerfile)
+	}
+
+	excludes, err := readDockerignore(contextDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	relDockerfile, err := filepath.Rel(contextDir, dockerfile)
+	if err != nil {
+		return nil, "", err
+	}
+	relDockerfile = filepath.ToSlash(relDockerfile)
+	outside := strings.HasPrefix(relDockerfile, "../")
+
+	// The daemon needs to see the Dockerfile and .dockerignore even if
+	// they are excluded; it removes them from the context itself.
+	if !outside {
+		excludes = append(excludes, "!"+relDockerfile)
+	}
+	excludes = append(excludes, "!.dockerignore")
+
+	content, err := archive.TarWithOptions(contextDir, &archive.TarOptions{
+		ExcludePatterns: excludes,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !outside {
+		return content, relDockerfile, nil
+	}
+
+	data, err := ioutil.ReadFile(dockerfile)
+	if err != nil {
+		content.Close()
+		return nil, "", err
+	}
+
+	name := fmt.Sprintf(".dockerfile.%d", time.Now().UnixNano())
+	content = archive.ReplaceFileTarWrapper(content, map[string]archive.TarModifierFunc{
+		name: func(_ string, _ *tar.Header, _ io.Reader) (*tar.Header, []byte, error) {
+			header := &tar.Header{
+				Name:     name,
+				Mode:     0600,
+				ModTime:  time.Now(),
+				Typeflag: tar.TypeReg,
+				Size:     int64(len(data)),
+			}
+			return header, data, nil
+		},
+	})
+	return content, name, nil
+}
+
+// BuildImage builds an image from opts.ContextDir and returns its ID. The
+// credentials of every known registry are sent, resolved as for PullImage,
+// so private base images can be pulled. Errors reported by the daemon during the build are returned as an
+// *ImageStreamError.
+func (di *DockerInterface) BuildImage(ctx context.Context, opts BuildOptions) (string, error) {
+	content, dockerfile, err := buildContext(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to build image: %s", err)
+	}
+	defer content.Close()
+
+	auths, err := di.allAuths()
+	if err != nil {
+		return "", fmt.Errorf("failed to build image: %s", err)
+	}
+
+	buildArgs := make(map[string]*string, len(opts.BuildArgs))
+	for key, value := range opts.BuildArgs {
+		value := value
+		buildArgs[key] = &value
+	}
+
+	response, err := di.Client.ImageBuild(ctx, content, types.ImageBuildOptions{
+		Dockerfile:  dockerfile,
+		BuildArgs:   buildArgs,
+		Labels:      opts.Labels,
+		Tags:        opts.Tags,
+		Target:      opts.Target,
+		Platform:    opts.Platform,
+		NoCache:     opts.NoCache,
+		PullParent:  opts.Pull,
+		Remove:      true,
+		AuthConfigs: auths,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build image: %s", err)
+	}
+	defer response.Body.Close()
+
+	var result buildResult
+	var partial string
+
+	if err := decodeStream(response.Body, "build", func(msg streamMessage) {
+		if msg.Aux != nil {
+			json.Unmarshal(*msg.Aux, &result)
+		}
+		if opts.Output == nil || msg.Stream == "" {
+			return
+		}
+
+		lines := strings.Split(partial+msg.Stream, "\n")
+		for _, line := range lines[:len(lines)-1] {
+			opts.Output(line)
+		}
+		partial = lines[len(lines)-1]
+	}); err != nil {
+		return "", err
+	}
+
+	if opts.Output != nil && partial != "" {
+		opts.Output(partial)
+	}
+	return result.ID, di.RefreshImages(ctx)
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Create a build context with a .dockerignore in a temporary directory.
+func newBuildContext() string {
+	dir, _ := ioutil.TempDir("", "dockland")
+	files := map[string]string{
+		"Dockerfile":    "FROM busybox\nARG MSG\nCOPY app.txt /\nRUN echo $MSG\n",
+		".dockerignore": "# ignore logs\n*.log\n/secret\n!keep.log\n",
+		"app.txt":       "hello\n",
+		"debug.log":     "debug\n",
+		"keep.log":      "keep\n",
+		"secret":        "hunter2\n",
+	}
+
+	for name, content := range files {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	return dir
+}
+
+// List the names of the files in a tar stream.
+func tarNames(r io.Reader) []string {
+	var names []string
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestReadDockerignore
+func TestReadDockerignore(t *testing.T) {
+	dir := newBuildContext()
+	defer os.RemoveAll(dir)
+
+	want := []string{"*.log", "secret", "!keep.log"}
+	got, err := readDockerignore(dir)
+	if err != nil {
+		t.Logf("got error reading .dockerignore: %s", err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got patterns %v, want %v", got, want)
+	}
+}
+
+// TestBuildContext
+func TestBuildContext(t *testing.T) {
+	dir := newBuildContext()
+	defer os.RemoveAll(dir)
+
+	content, dockerfile, err := buildContext(BuildOptions{ContextDir: dir})
+	if err != nil {
+		t.Logf("got error creating build context: %s", err)
+		t.FailNow()
+	}
+	defer content.Close()
+
+	want := []string{".dockerignore", "Dockerfile", "app.txt", "keep.log"}
+	if got := tarNames(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("got context files %v, want %v", got, want)
+	}
+	if dockerfile != "Dockerfile" {
+		t.Errorf("got dockerfile %s, want Dockerfile", dockerfile)
+	}
+
+	outside, _ := ioutil.TempFile("", "Dockerfile")
+	outside.WriteString("FROM busybox\n")
+	outside.Close()
+	defer os.Remove(outside.Name())
+
+	content, dockerfile, err = buildContext(BuildOptions{ContextDir: dir, Dockerfile: outside.Name()})
+	if err != nil {
+		t.Logf("got error creating build context: %s", err)
+		t.FailNow()
+	}
+	defer content.Close()
+
+	if !strings.HasPrefix(dockerfile, ".dockerfile.") {
+		t.Errorf("got dockerfile %s, want generated name", dockerfile)
+	}
+	if names := tarNames(content); len(names) != len(want)+1 {
+		t.Errorf("got %d context files, want %d", len(names), len(want)+1)
+	}
+}
+
+// TestBuildImage
+func TestBuildImage(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	dir := newBuildContext()
+	defer os.RemoveAll(dir)
+
+	var output []string
+	id, err := di.BuildImage(ctx, BuildOptions{
+		ContextDir: dir,
+		BuildArgs:  map[string]string{"MSG": "built by dockland"},
+		Tags:       []string{"dockland/build:test"},
+		Output:     func(line string) { output = append(output, line) },
+	})
+	if err != nil {
+		t.Logf("got error building image: %s", err)
+		t.FailNow()
+	}
+	defer di.RemoveImage(ctx, id)
+
+	if _, err := di.ResolveImage("dockland/build:test"); err != nil {
+		t.Errorf("got error finding built image: %s", err)
+	}
+	if !strings.Contains(strings.Join(output, "\n"), "built by dockland") {
+		t.Error("build output is missing RUN output")
+	}
+}
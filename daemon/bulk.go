@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultBulkWorkers is the number of concurrent requests made by bulk
+// operations when BulkOptions.Workers is not set.
+const DefaultBulkWorkers = 4
+
+// BulkOptions controls how bulk container operations are run.
+type BulkOptions struct {
+	// Workers is the maximum number of concurrent requests to the daemon.
+	Workers int
+	// Timeout is the stop grace period used by StopContainers and
+	// RestartContainers. nil uses each container's own stop timeout.
+	Timeout *time.Duration
+}
+
+// BulkResults maps each container ID passed to a bulk operation to the
+// error returned for it, or nil if the operation succeeded.
+type BulkResults map[string]error
+
+// Failed returns the IDs whose operation returned an error.
+func (br BulkResults) Failed() []string {
+	var failed []string
+
+	for id, err := range br {
+		if err != nil {
+			failed = append(failed, id)
+		}
+	}
+	return failed
+}
+
+// bulkContainers runs op on every ID using at most opts.Workers concurrent
+// requests, then refreshes the container list once.
+func (di *DockerInterface) bulkContainers(ctx context.Context, ids []string,
+	opts BulkOptions, op func(context.Context, string) error) (BulkResults, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(BulkResults, len(ids))
+	sem := make(chan struct{}, workers)
+
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := op(ctx, id)
+			mu.Lock()
+			results[id] = err
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	return results, di.RefreshContainers(ctx)
+}
+
+// StartContainers starts each stopped container in ids.
+func (di *DockerInterface) StartContainers(ctx context.Context,
+	ids []string, opts BulkOptions) (BulkResults, error) {
+	return di.bulkContainers(ctx, ids, opts, di.startContainer)
+}
+
+// StopContainers stops each running container in ids.
+func (di *DockerInterface) StopContainers(ctx context.Context,
+	ids []string, opts BulkOptions) (BulkResults, error) {
+	return di.bulkContainers(ctx, ids, opts, func(ctx context.Context, id string) error {
+		return di.stopContainer(ctx, id, opts.Timeout)
+	})
+}
+
+// RestartContainers restarts each container in ids.
+func (di *DockerInterface) RestartContainers(ctx context.Context,
+	ids []string, opts BulkOptions) (BulkResults, error) {
+	return di.bulkContainers(ctx, ids, opts, func(ctx context.Context, id string) error {
+		return di.restartContainer(ctx, id, opts.Timeout)
+	})
+}
+
+// RemoveContainers removes each container in ids.
+func (di *DockerInterface) RemoveContainers(ctx context.Context,
+	ids []string, opts BulkOptions) (BulkResults, error) {
+	return di.bulkContainers(ctx, ids, opts, di.removeContainer)
+}
+
+// PauseContainers pauses each running container in ids.
+func (di *DockerInterface) PauseContainers(ctx context.Context,
+	ids []string, opts BulkOptions) (BulkResults, error) {
+	return di.bulkContainers(ctx, ids, opts, di.pauseContainer)
+}
+
+// UnpauseContainers unpauses each paused container in ids.
+func (di *DockerInterface) UnpauseContainers(ctx context.Context,
+	ids []string, opts BulkOptions) (BulkResults, error) {
+	return di.bulkContainers(ctx, ids, opts, di.unpauseContainer)
+}
@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// Create n test containers and return their IDs.
+func newBulkContainers(di *DockerInterface, n int) []string {
+	var ids []string
+
+	for i := 0; i < n; i++ {
+		opts := map[string]string{"name": fmt.Sprintf("bulk%d", i), "image": "nginx"}
+		if id, err := di.NewContainer(context.TODO(), opts); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// TestStartStopContainers
+func TestStartStopContainers(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	ids := newBulkContainers(di, 3)
+	defer di.RemoveContainers(ctx, ids, BulkOptions{})
+
+	results, err := di.StartContainers(ctx, ids, BulkOptions{Workers: 2})
+	if err != nil {
+		t.Logf("got error starting containers: %s", err)
+		t.FailNow()
+	}
+	if failed := results.Failed(); len(failed) > 0 {
+		t.Errorf("got %d failed starts, want 0", len(failed))
+	}
+
+	results, err = di.StopContainers(ctx, append(ids, "no_such_container"), BulkOptions{})
+	if err != nil {
+		t.Logf("got error stopping containers: %s", err)
+		t.FailNow()
+	}
+	if len(results) != len(ids)+1 {
+		t.Errorf("got %d results, want %d", len(results), len(ids)+1)
+	}
+	if results["no_such_container"] == nil {
+		t.Error("expected error stopping missing container")
+	}
+}
+
+// TestRemoveContainers
+func TestRemoveContainers(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	ids := newBulkContainers(di, 3)
+	want := di.NumContainers() - len(ids)
+
+	results, err := di.RemoveContainers(ctx, ids, BulkOptions{})
+	if err != nil {
+		t.Logf("got error removing containers: %s", err)
+		t.FailNow()
+	}
+	if failed := results.Failed(); len(failed) > 0 {
+		t.Errorf("got %d failed removals, want 0", len(failed))
+	}
+	if di.NumContainers() != want {
+		t.Errorf("got %d containers, want %d", di.NumContainers(), want)
+	}
+}
@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/client"
+)
+
+// cloneSourcePath is where clone helper containers mount the source volume.
+const cloneSourcePath = "/source"
+
+// CloneOptions controls how a volume's contents are copied to a new volume.
+type CloneOptions struct {
+	// Volume holds the NewVolume options for the new volume, such as
+	// "driver", "options" and "labels". The name is always the destination
+	// and the driver defaults to the source volume's driver.
+	Volume map[string]string
+	// HelperImage overrides DefaultHelperImage.
+	HelperImage string
+	// Gzip compresses the data streamed between daemons by MigrateVolume.
+	Gzip bool
+	// Progress, if set, is called with the total number of bytes streamed
+	// so far by MigrateVolume.
+	Progress func(bytes int64)
+}
+
+// newCloneVolume creates the destination volume of a clone or migration on
+// di, failing if it already exists.
+func (di *DockerInterface) newCloneVolume(ctx context.Context,
+	dst string, driver string, opts CloneOptions) error {
+	if _, err := di.Client.VolumeInspect(ctx, dst); err == nil {
+		return fmt.Errorf("volume %s already exists", dst)
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	volumeOpts := map[string]string{"driver": driver}
+	for key, value := range opts.Volume {
+		volumeOpts[key] = value
+	}
+	volumeOpts["name"] = dst
+
+	_, err := di.NewVolume(ctx, volumeOpts)
+	return err
+}
+
+// CloneVolume creates the volume dst and copies the contents of src into
+// it through a helper container, preserving ownership and permissions. The
+// new volume may use a different driver and driver options than the source.
+// If the copy fails the new volume is removed.
+func (di *DockerInterface) CloneVolume(ctx context.Context,
+	src string, dst string, opts CloneOptions) error {
+	source, err := di.Client.VolumeInspect(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to clone volume: %s", err)
+	}
+
+	if err := di.newCloneVolume(ctx, dst, source.Driver, opts); err != nil {
+		return fmt.Errorf("failed to clone volume: %s", err)
+	}
+
+	volumes := src + ":" + cloneSourcePath + ":ro," + dst + ":" + volumeHelperPath
+	id, err := di.newVolumeHelper(ctx, opts.HelperImage, volumes,
+		"cp,-a,"+cloneSourcePath+"/.,"+volumeHelperPath+"/")
+	if err == nil {
+		err = di.runVolumeHelper(ctx, id)
+		di.removeVolumeHelper(id)
+	}
+
+	if err != nil {
+		di.RemoveVolume(ctx, dst)
+		return fmt.Errorf("failed to clone volume: %s", err)
+	}
+	return nil
+}
+
+// MigrateVolume creates the volume dst on target and streams the contents
+// of src on di into it, so data can move between two daemons. If either
+// side of the transfer fails the new volume is removed from target.
+func (di *DockerInterface) MigrateVolume(ctx context.Context,
+	src string, target *DockerInterface, dst string, opts CloneOptions) error {
+	source, err := di.Client.VolumeInspect(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to migrate volume: %s", err)
+	}
+
+	if err := target.newCloneVolume(ctx, dst, source.Driver, opts); err != nil {
+		return fmt.Errorf("failed to migrate volume: %s", err)
+	}
+
+	pr, pw := io.Pipe()
+	backup := make(chan error, 1)
+	go func() {
+		err := di.BackupVolume(ctx, src, pw, VolumeBackupOptions{
+			Gzip:        opts.Gzip,
+			HelperImage: opts.HelperImage,
+			Progress:    opts.Progress,
+		})
+		pw.CloseWithError(err)
+		backup <- err
+	}()
+
+	err = target.RestoreVolume(ctx, dst, pr, VolumeRestoreOptions{HelperImage: opts.HelperImage})
+	pr.CloseWithError(err)
+
+	// Wait for the backup to finish; a restore error is reported first, as
+	// it is what made the backup fail to write.
+	if backupErr := <-backup; err == nil {
+		err = backupErr
+	}
+
+	if err != nil {
+		target.RemoveVolume(ctx, dst)
+		return fmt.Errorf("failed to migrate volume: %s", err)
+	}
+	return nil
+}
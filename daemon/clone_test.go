@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// TestCloneVolume
+func TestCloneVolume(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	src, _ := di.NewVolume(ctx, map[string]string{"name": "clone_source"})
+	defer di.RemoveVolume(ctx, src)
+	di.RestoreVolume(ctx, src, newTestArchive(map[string]string{"data/db": "rows"}),
+		VolumeRestoreOptions{})
+
+	opts := CloneOptions{Volume: map[string]string{"labels": "cloned=true"}}
+	if err := di.CloneVolume(ctx, src, "clone_dest", opts); err != nil {
+		t.Logf("got error cloning volume: %s", err)
+		t.FailNow()
+	}
+	defer di.RemoveVolume(ctx, "clone_dest")
+
+	volume, _ := getVolume("clone_dest")
+	if volume.Driver != "local" || volume.Labels["cloned"] != "true" {
+		t.Errorf("got driver %s and labels %v", volume.Driver, volume.Labels)
+	}
+
+	var backup bytes.Buffer
+	di.BackupVolume(ctx, "clone_dest", &backup, VolumeBackupOptions{})
+	if files, _ := readTestArchive(&backup); files["./data/db"] != "rows" {
+		t.Errorf("got cloned files %v", files)
+	}
+
+	if err := di.CloneVolume(ctx, src, "clone_dest", opts); err == nil {
+		t.Error("expected error cloning onto existing volume")
+	}
+}
+
+// TestMigrateVolume
+func TestMigrateVolume(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	target, _ := NewInterface(ctx)
+
+	src, _ := di.NewVolume(ctx, map[string]string{"name": "migrate_source"})
+	defer di.RemoveVolume(ctx, src)
+	di.RestoreVolume(ctx, src, newTestArchive(map[string]string{"a": "1"}),
+		VolumeRestoreOptions{})
+
+	var progress int64
+	opts := CloneOptions{Gzip: true, Progress: func(n int64) { progress = n }}
+	if err := di.MigrateVolume(ctx, src, target, "migrate_dest", opts); err != nil {
+		t.Logf("got error migrating volume: %s", err)
+		t.FailNow()
+	}
+	defer target.RemoveVolume(ctx, "migrate_dest")
+
+	var backup bytes.Buffer
+	target.BackupVolume(ctx, "migrate_dest", &backup, VolumeBackupOptions{})
+	if files, _ := readTestArchive(&backup); files["./a"] != "1" {
+		t.Errorf("got migrated files %v", files)
+	}
+	if progress == 0 {
+		t.Error("got no progress reported")
+	}
+}
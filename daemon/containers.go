@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -12,8 +13,11 @@ import (
 )
 
 // newContainerCreateConfig takes a map of options and creates the necessary
-// configuration structs to create a new container.
-func newContainerCreateConfig(opts map[string]string) *types.ContainerCreateConfig {
+// configuration structs to create a new container. Env, cmd and entrypoint
+// are comma-separated lists split as in splitArgList, and a malformed list
+// is reported as *OptionError. Volumes is a comma-separated list of binds
+// in docker run's -v format, such as "data:/var/lib/data:ro" or "/srv:/srv".
+func newContainerCreateConfig(opts map[string]string) (*types.ContainerCreateConfig, error) {
 	config := &types.ContainerCreateConfig{
 		Config:     &container.Config{},
 		HostConfig: &container.HostConfig{},
@@ -37,22 +41,22 @@ func newContainerCreateConfig(opts map[string]string) *types.ContainerCreateConf
 		config.HostConfig.PortBindings = nat.PortMap{nat.Port(port + "/tcp"): bindings}
 	}
 
-	if env := opts["env"]; env != "" {
-		for _, arg := range strings.Split(env, ",") {
-			config.Config.Env = append(config.Config.Env, strings.TrimSpace(arg))
-		}
+	var err error
+	if config.Config.Env, err = splitArgList("env", opts["env"]); err != nil {
+		return nil, err
 	}
-	if cmd := opts["cmd"]; cmd != "" {
-		for _, arg := range strings.Split(cmd, ",") {
-			config.Config.Cmd = append(config.Config.Cmd, strings.TrimSpace(arg))
-		}
+	if config.Config.Cmd, err = splitArgList("cmd", opts["cmd"]); err != nil {
+		return nil, err
+	}
+	if config.Config.Entrypoint, err = splitArgList("entrypoint", opts["entrypoint"]); err != nil {
+		return nil, err
 	}
-	if entryPoint := opts["entrypoint"]; entryPoint != "" {
-		for _, arg := range strings.Split(entryPoint, ",") {
-			config.Config.Entrypoint = append(config.Config.Entrypoint, strings.TrimSpace(arg))
+	if volumes := opts["volumes"]; volumes != "" {
+		for _, bind := range strings.Split(volumes, ",") {
+			config.HostConfig.Binds = append(config.HostConfig.Binds, strings.TrimSpace(bind))
 		}
 	}
-	return config
+	return config, nil
 }
 
 // InspectContainer returns the JSON file generated by the Docker Engine for the
@@ -66,16 +70,87 @@ func (di *DockerInterface) InspectContainer(ctx context.Context,
 	return response, nil
 }
 
+// ContainerProcess is a single row from a container's process list, keyed
+// by the column titles reported by ps.
+type ContainerProcess map[string]string
+
+// ContainerChanges holds the paths that have changed in a container's
+// filesystem, grouped by the kind of change.
+type ContainerChanges struct {
+	Added   []string
+	Changed []string
+	Deleted []string
+}
+
+// Kinds of filesystem changes reported by the Docker Engine.
+const (
+	changeModified uint8 = iota
+	changeAdded
+	changeDeleted
+)
+
+// ContainerTop returns the processes running inside a container. psArgs are
+// passed through to ps and may be empty to use the daemon's defaults.
+func (di *DockerInterface) ContainerTop(ctx context.Context,
+	id string, psArgs []string) ([]ContainerProcess, error) {
+	response, err := di.Client.ContainerTop(ctx, id, psArgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list container processes: %s", err)
+	}
+
+	processes := make([]ContainerProcess, 0, len(response.Processes))
+	for _, row := range response.Processes {
+		process := make(ContainerProcess, len(response.Titles))
+
+		for i, title := range response.Titles {
+			if i < len(row) {
+				process[title] = row[i]
+			}
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
+
+// ContainerDiff returns the changes made to a container's filesystem since
+// it was created from its image.
+func (di *DockerInterface) ContainerDiff(ctx context.Context,
+	id string) (ContainerChanges, error) {
+	var changes ContainerChanges
+
+	response, err := di.Client.ContainerDiff(ctx, id)
+	if err != nil {
+		return changes, fmt.Errorf("failed to diff container: %s", err)
+	}
+
+	for _, item := range response {
+		switch item.Kind {
+		case changeAdded:
+			changes.Added = append(changes.Added, item.Path)
+		case changeModified:
+			changes.Changed = append(changes.Changed, item.Path)
+		case changeDeleted:
+			changes.Deleted = append(changes.Deleted, item.Path)
+		}
+	}
+	return changes, nil
+}
+
 // NewContainer creates a new container with the provided options and
-// returns the container's ID.
+// returns the container's ID. Malformed env, cmd or entrypoint lists are
+// reported as *OptionError.
 func (di *DockerInterface) NewContainer(ctx context.Context,
 	opts map[string]string) (string, error) {
-	config := newContainerCreateConfig(opts)
+	config, err := newContainerCreateConfig(opts)
+	if err != nil {
+		return "", err
+	}
+
 	response, err := di.Client.ContainerCreate(ctx, config.Config,
 		config.HostConfig, nil, nil, config.Name)
 
 	if err == nil {
-		return response.ID, di.RefreshContainers(ctx)
+		return response.ID, di.RefreshContainer(ctx, response.ID)
 	} else if !client.IsErrNotFound(err) {
 		return "", fmt.Errorf("failed to create new container: %s", err)
 	}
@@ -90,32 +165,143 @@ func (di *DockerInterface) NewContainer(ctx context.Context,
 		return "", fmt.Errorf("failed to create new container: %s", err)
 	}
 
-	return response.ID, di.RefreshContainers(ctx)
+	return response.ID, di.RefreshContainer(ctx, response.ID)
+}
+
+// restartContainer restarts a container without refreshing the container list.
+func (di *DockerInterface) restartContainer(ctx context.Context,
+	id string, timeout *time.Duration) error {
+	if err := di.Client.ContainerRestart(ctx, id, timeout); err != nil {
+		return fmt.Errorf("failed to restart container: %s", err)
+	}
+	return nil
 }
 
 // RestartContainer restarts a running container.
 func (di *DockerInterface) RestartContainer(ctx context.Context, id string) error {
-	if err := di.Client.ContainerRestart(ctx, id, nil); err != nil {
-		return fmt.Errorf("failed to restart container: %s", err)
+	return di.RestartContainerWithTimeout(ctx, id, nil)
+}
+
+// RestartContainerWithTimeout restarts a running container. timeout is how
+// long to wait for the container to stop before killing it; nil uses the
+// container's own stop timeout.
+func (di *DockerInterface) RestartContainerWithTimeout(ctx context.Context,
+	id string, timeout *time.Duration) error {
+	if err := di.restartContainer(ctx, id, timeout); err != nil {
+		return err
 	}
-	return di.RefreshContainers(ctx)
+	return di.RefreshContainer(ctx, id)
+}
+
+// stopContainer stops a container without refreshing the container list.
+func (di *DockerInterface) stopContainer(ctx context.Context,
+	id string, timeout *time.Duration) error {
+	if err := di.Client.ContainerStop(ctx, id, timeout); err != nil {
+		return fmt.Errorf("failed to stop container: %s", err)
+	}
+	return nil
 }
 
 // StopContainer stops a running container.
 func (di *DockerInterface) StopContainer(ctx context.Context, id string) error {
-	if err := di.Client.ContainerStop(ctx, id, nil); err != nil {
-		return fmt.Errorf("failed to stop container: %s", err)
+	return di.StopContainerWithTimeout(ctx, id, nil)
+}
+
+// StopContainerWithTimeout stops a running container. timeout is how long
+// to wait for the container to stop before killing it; nil uses the
+// container's own stop timeout.
+func (di *DockerInterface) StopContainerWithTimeout(ctx context.Context,
+	id string, timeout *time.Duration) error {
+	if err := di.stopContainer(ctx, id, timeout); err != nil {
+		return err
 	}
-	return di.RefreshContainers(ctx)
+	return di.RefreshContainer(ctx, id)
 }
 
-// StartContainer starts a stopped container.
-func (di *DockerInterface) StartContainer(ctx context.Context, id string) error {
+// pauseContainer pauses a container without refreshing the container list.
+func (di *DockerInterface) pauseContainer(ctx context.Context, id string) error {
+	if err := di.Client.ContainerPause(ctx, id); err != nil {
+		return fmt.Errorf("failed to pause container: %s", err)
+	}
+	return nil
+}
+
+// PauseContainer suspends all processes in a running container.
+func (di *DockerInterface) PauseContainer(ctx context.Context, id string) error {
+	if err := di.pauseContainer(ctx, id); err != nil {
+		return err
+	}
+	return di.RefreshContainer(ctx, id)
+}
+
+// unpauseContainer unpauses a container without refreshing the container list.
+func (di *DockerInterface) unpauseContainer(ctx context.Context, id string) error {
+	if err := di.Client.ContainerUnpause(ctx, id); err != nil {
+		return fmt.Errorf("failed to unpause container: %s", err)
+	}
+	return nil
+}
+
+// UnpauseContainer resumes all processes in a paused container.
+func (di *DockerInterface) UnpauseContainer(ctx context.Context, id string) error {
+	if err := di.unpauseContainer(ctx, id); err != nil {
+		return err
+	}
+	return di.RefreshContainer(ctx, id)
+}
+
+// KillContainer sends signal to a running container. signal may be a name
+// such as "SIGHUP" or a number; an empty signal sends SIGKILL.
+func (di *DockerInterface) KillContainer(ctx context.Context,
+	id string, signal string) error {
+	if err := di.Client.ContainerKill(ctx, id, signal); err != nil {
+		return fmt.Errorf("failed to kill container: %s", err)
+	}
+	return di.RefreshContainer(ctx, id)
+}
+
+// ContainerExit holds the result of waiting on a container.
+type ContainerExit struct {
+	StatusCode int64
+	Error      string
+}
+
+// WaitContainer blocks until a container meets condition and returns its
+// exit status. condition is one of container.WaitConditionNotRunning,
+// container.WaitConditionNextExit or container.WaitConditionRemoved; an
+// empty condition waits until the container is not running.
+func (di *DockerInterface) WaitContainer(ctx context.Context,
+	id string, condition container.WaitCondition) (ContainerExit, error) {
+	var exit ContainerExit
+
+	statusCh, errCh := di.Client.ContainerWait(ctx, id, condition)
+	select {
+	case err := <-errCh:
+		return exit, fmt.Errorf("failed to wait for container: %s", err)
+	case status := <-statusCh:
+		exit.StatusCode = status.StatusCode
+		if status.Error != nil {
+			exit.Error = status.Error.Message
+		}
+	}
+	return exit, di.RefreshContainer(ctx, id)
+}
+
+// startContainer starts a container without refreshing the container list.
+func (di *DockerInterface) startContainer(ctx context.Context, id string) error {
 	if err := di.Client.ContainerStart(
 		ctx, id, types.ContainerStartOptions{}); err != nil {
 		return fmt.Errorf("failed to start container: %s", err)
 	}
-	return di.RefreshContainers(ctx)
+	return nil
+}
+
+// StartContainer starts a stopped container.
+func (di *DockerInterface) StartContainer(ctx context.Context, id string) error {
+	if err := di.startContainer(ctx, id); err != nil {
+		return err
+	}
+	return di.RefreshContainer(ctx, id)
 }
 
 // RenameContainer renames a container to name.
@@ -124,16 +310,24 @@ func (di *DockerInterface) RenameContainer(ctx context.Context,
 	if err := di.Client.ContainerRename(ctx, id, name); err != nil {
 		return fmt.Errorf("failed to rename container: %s", err)
 	}
-	return di.RefreshContainers(ctx)
+	return di.RefreshContainer(ctx, name)
 }
 
-// RemoveContainer removes a container.
-func (di *DockerInterface) RemoveContainer(ctx context.Context, id string) error {
+// removeContainer removes a container without refreshing the container list.
+func (di *DockerInterface) removeContainer(ctx context.Context, id string) error {
 	if err := di.Client.ContainerRemove(
 		ctx, id, types.ContainerRemoveOptions{Force: true}); err != nil {
 		return fmt.Errorf("failed to remove container: %s", err)
 	}
-	return di.RefreshContainers(ctx)
+	return nil
+}
+
+// RemoveContainer removes a container.
+func (di *DockerInterface) RemoveContainer(ctx context.Context, id string) error {
+	if err := di.removeContainer(ctx, id); err != nil {
+		return err
+	}
+	return di.RefreshContainer(ctx, id)
 }
 
 // NumContainers returns the current number of containers.
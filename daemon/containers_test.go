@@ -2,11 +2,12 @@ package daemon
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 )
 
 // Used to compare containers.
@@ -21,13 +22,7 @@ type containerCompare struct {
 // Get container resource by name.
 func getContainer(name string) (types.Container, error) {
 	di, _ := NewInterface(context.TODO())
-
-	for _, container := range di.Containers {
-		if len(container.Names) > 0 && strings.TrimLeft(container.Names[0], "/") == name {
-			return container, nil
-		}
-	}
-	return types.Container{}, fmt.Errorf("no container %s found", name)
+	return di.ResolveContainer(name)
 }
 
 // TestInspectContainer
@@ -47,6 +42,62 @@ func TestInspectContainer(t *testing.T) {
 	}
 }
 
+// TestContainerTop
+func TestContainerTop(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	if err := di.StartContainer(ctx, conID); err != nil {
+		t.Logf("got error starting container: %s", err)
+		t.FailNow()
+	}
+
+	processes, err := di.ContainerTop(ctx, conID, nil)
+	if err != nil {
+		t.Logf("got error listing processes: %s", err)
+		t.FailNow()
+	}
+	if len(processes) == 0 {
+		t.Error("got no processes for running container")
+	} else if _, ok := processes[0]["PID"]; !ok {
+		t.Error("process row is missing PID column")
+	}
+	if _, err := di.ContainerTop(ctx, "no_such_container", nil); err == nil {
+		t.Error("expected error listing processes")
+	}
+}
+
+// TestContainerDiff
+func TestContainerDiff(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	if err := di.StartContainer(ctx, conID); err != nil {
+		t.Logf("got error starting container: %s", err)
+		t.FailNow()
+	}
+
+	changes, err := di.ContainerDiff(ctx, conID)
+	if err != nil {
+		t.Logf("got error diffing container: %s", err)
+		t.FailNow()
+	}
+	if len(changes.Added)+len(changes.Changed)+len(changes.Deleted) == 0 {
+		t.Error("expected filesystem changes for running nginx container")
+	}
+	if _, err := di.ContainerDiff(ctx, "no_such_container"); err == nil {
+		t.Error("expected error diffing container")
+	}
+}
+
 // TestNewContainer
 func TestNewContainer(t *testing.T) {
 	tables := []struct {
@@ -154,6 +205,92 @@ func TestStopContainer(t *testing.T) {
 	}
 }
 
+// TestStopContainerTimeout
+func TestStopContainerTimeout(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+	timeout := time.Second
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	di.StartContainer(ctx, conID)
+	if err := di.StopContainerWithTimeout(ctx, conID, &timeout); err != nil {
+		t.Errorf("got error stopping container: %s", err)
+	}
+}
+
+// TestPauseContainer
+func TestPauseContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	di.StartContainer(ctx, conID)
+	if err := di.PauseContainer(ctx, conID); err != nil {
+		t.Logf("got error pausing container: %s", err)
+		t.FailNow()
+	}
+
+	container, _ := getContainer(testContainer["name"])
+	if container.State != "paused" {
+		t.Errorf("got container state %s, want paused", container.State)
+	}
+
+	if err := di.UnpauseContainer(ctx, conID); err != nil {
+		t.Errorf("got error unpausing container: %s", err)
+	}
+}
+
+// TestKillContainer
+func TestKillContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	di.StartContainer(ctx, conID)
+	if err := di.KillContainer(ctx, conID, "SIGTERM"); err != nil {
+		t.Logf("got error killing container: %s", err)
+		t.FailNow()
+	}
+
+	if _, err := di.WaitContainer(ctx, conID, container.WaitConditionNotRunning); err != nil {
+		t.Logf("got error waiting for container: %s", err)
+		t.FailNow()
+	}
+	if err := di.KillContainer(ctx, conID, "SIGTERM"); err == nil {
+		t.Error("expected error killing stopped container")
+	}
+}
+
+// TestWaitContainer
+func TestWaitContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "alpine",
+		"entrypoint": "/bin/sh", "cmd": "-c,exit 3"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	di.StartContainer(ctx, conID)
+	exit, err := di.WaitContainer(ctx, conID, container.WaitConditionNotRunning)
+	if err != nil {
+		t.Logf("got error waiting for container: %s", err)
+		t.FailNow()
+	}
+	if exit.StatusCode != 3 {
+		t.Errorf("got exit status %d, want 3", exit.StatusCode)
+	}
+}
+
 // TestStartContainer
 func TestStartContainer(t *testing.T) {
 	ctx := context.TODO()
@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+)
+
+// CopyOptions controls how files are copied into and out of containers.
+type CopyOptions struct {
+	// FollowLink copies the target of a symbolic link rather than the link.
+	FollowLink bool
+	// CopyUIDGID preserves the UID and GID of copied files.
+	CopyUIDGID bool
+}
+
+// StatContainerPath returns information about a path inside a container.
+func (di *DockerInterface) StatContainerPath(ctx context.Context,
+	id string, path string) (types.ContainerPathStat, error) {
+	stat, err := di.Client.ContainerStatPath(ctx, id, path)
+	if err != nil {
+		return types.ContainerPathStat{}, fmt.Errorf("failed to stat container path: %s", err)
+	}
+	return stat, nil
+}
+
+// resolveContainerLink returns the path a symbolic link inside a container
+// points to. Relative link targets are resolved against the link's directory.
+func resolveContainerLink(linkPath string, stat types.ContainerPathStat) string {
+	if path.IsAbs(stat.LinkTarget) {
+		return stat.LinkTarget
+	}
+	dir, _ := archive.SplitPathDirEntry(linkPath)
+	return path.Join(dir, stat.LinkTarget)
+}
+
+// CopyFromContainer copies srcPath from a container into dstDir on the
+// local filesystem.
+func (di *DockerInterface) CopyFromContainer(ctx context.Context,
+	id string, srcPath string, dstDir string, opts CopyOptions) error {
+	var rebaseName string
+
+	if opts.FollowLink {
+		stat, err := di.StatContainerPath(ctx, id, srcPath)
+		if err != nil {
+			return fmt.Errorf("failed to copy from container: %s", err)
+		}
+
+		if stat.Mode&os.ModeSymlink != 0 {
+			linkTarget := resolveContainerLink(srcPath, stat)
+			srcPath, rebaseName = linkTarget, path.Base(srcPath)
+		}
+	}
+
+	content, stat, err := di.Client.CopyFromContainer(ctx, id, srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to copy from container: %s", err)
+	}
+	defer content.Close()
+
+	srcInfo := archive.CopyInfo{
+		Path:       srcPath,
+		Exists:     true,
+		IsDir:      stat.Mode.IsDir(),
+		RebaseName: rebaseName,
+	}
+
+	preArchive := content
+	if rebaseName != "" {
+		_, srcBase := archive.SplitPathDirEntry(srcPath)
+		preArchive = archive.RebaseArchiveEntries(content, srcBase, rebaseName)
+		defer preArchive.Close()
+	}
+
+	dstInfo, err := archive.CopyInfoDestinationPath(dstDir)
+	if err != nil {
+		return fmt.Errorf("failed to copy from container: %s", err)
+	}
+
+	extractDir, copyArchive, err := archive.PrepareArchiveCopy(preArchive, srcInfo, dstInfo)
+	if err != nil {
+		return fmt.Errorf("failed to copy from container: %s", err)
+	}
+	defer copyArchive.Close()
+
+	tarOpts := &archive.TarOptions{
+		NoLchown:             !opts.CopyUIDGID,
+		NoOverwriteDirNonDir: true,
+	}
+
+	if err := archive.Untar(copyArchive, extractDir, tarOpts); err != nil {
+		return fmt.Errorf("failed to copy from container: %s", err)
+	}
+	return nil
+}
+
+// CopyToContainer copies srcPath from the local filesystem to dstPath inside
+// a container. The container does not need to be running.
+func (di *DockerInterface) CopyToContainer(ctx context.Context,
+	id string, srcPath string, dstPath string, opts CopyOptions) error {
+	dstInfo := archive.CopyInfo{Path: dstPath}
+
+	stat, err := di.Client.ContainerStatPath(ctx, id, dstPath)
+	if err == nil && stat.Mode&os.ModeSymlink != 0 && opts.FollowLink {
+		dstInfo.Path = resolveContainerLink(dstPath, stat)
+		stat, err = di.Client.ContainerStatPath(ctx, id, dstInfo.Path)
+	}
+	if err == nil {
+		dstInfo.Exists, dstInfo.IsDir = true, stat.Mode.IsDir()
+	}
+
+	srcInfo, err := archive.CopyInfoSourcePath(srcPath, opts.FollowLink)
+	if err != nil {
+		return fmt.Errorf("failed to copy to container: %s", err)
+	}
+
+	srcArchive, err := archive.TarResource(srcInfo)
+	if err != nil {
+		return fmt.Errorf("failed to copy to container: %s", err)
+	}
+	defer srcArchive.Close()
+
+	dstDir, copyArchive, err := archive.PrepareArchiveCopy(srcArchive, srcInfo, dstInfo)
+	if err != nil {
+		return fmt.Errorf("failed to copy to container: %s", err)
+	}
+	defer copyArchive.Close()
+
+	if err := di.Client.CopyToContainer(ctx, id, dstDir, copyArchive,
+		types.CopyToContainerOptions{CopyUIDGID: opts.CopyUIDGID}); err != nil {
+		return fmt.Errorf("failed to copy to container: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCopyToContainer
+func TestCopyToContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	srcDir, _ := ioutil.TempDir("", "dockland")
+	defer os.RemoveAll(srcDir)
+
+	srcFile := filepath.Join(srcDir, "test.conf")
+	ioutil.WriteFile(srcFile, []byte("listen 80;\n"), 0644)
+
+	if err := di.CopyToContainer(ctx, conID, srcFile, "/etc/", CopyOptions{}); err != nil {
+		t.Logf("got error copying to container: %s", err)
+		t.FailNow()
+	}
+
+	stat, err := di.StatContainerPath(ctx, conID, "/etc/test.conf")
+	if err != nil {
+		t.Logf("got error stating container path: %s", err)
+		t.FailNow()
+	}
+	if stat.Size != int64(len("listen 80;\n")) {
+		t.Errorf("got size %d for copied file, want %d", stat.Size, len("listen 80;\n"))
+	}
+	if err := di.CopyToContainer(ctx, "no_such_container", srcFile, "/etc/",
+		CopyOptions{}); err == nil {
+		t.Error("expected error copying to container")
+	}
+}
+
+// TestCopyFromContainer
+func TestCopyFromContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	dstDir, _ := ioutil.TempDir("", "dockland")
+	defer os.RemoveAll(dstDir)
+
+	if err := di.CopyFromContainer(ctx, conID, "/etc/nginx/nginx.conf", dstDir,
+		CopyOptions{}); err != nil {
+		t.Logf("got error copying from container: %s", err)
+		t.FailNow()
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "nginx.conf")); err != nil {
+		t.Errorf("got error finding copied file: %s", err)
+	}
+	if err := di.CopyFromContainer(ctx, conID, "/no/such/path", dstDir,
+		CopyOptions{}); err == nil {
+		t.Error("expected error copying from container")
+	}
+}
@@ -7,6 +7,7 @@ package daemon
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
@@ -31,6 +32,11 @@ type DockerInterface struct {
 	Info       types.Info
 	Networks   []types.NetworkResource
 	Volumes    []*types.Volume
+
+	// scope holds the label filters applied to every resource refresh.
+	scope filters.Args
+	// auths holds registry credentials set with SetAuth or Login.
+	auths map[string]types.AuthConfig
 }
 
 // Error is called whenever the daemon fails to send us an updated resource list.
@@ -38,25 +44,131 @@ func (r *ResourceRefreshError) Error() string {
 	return fmt.Sprintf("failed to refresh %s: %s", r.Resource, r.Err)
 }
 
+// scopeFilters returns extra combined with the DockerInterface's label scope.
+func (di *DockerInterface) scopeFilters(extra filters.Args) filters.Args {
+	args := extra.Clone()
+
+	for _, label := range di.scope.Get("label") {
+		args.Add("label", label)
+	}
+	return args
+}
+
+// scopeLabels returns the labels a resource needs to fall within the
+// DockerInterface's label scope. A selector without a value becomes a label
+// with an empty value.
+func (di *DockerInterface) scopeLabels() map[string]string {
+	labels := make(map[string]string)
+
+	for _, selector := range di.scope.Get("label") {
+		keyValue := strings.SplitN(selector, "=", 2)
+		labels[keyValue[0]] = ""
+		if len(keyValue) == 2 {
+			labels[keyValue[0]] = keyValue[1]
+		}
+	}
+	return labels
+}
+
 // RefreshContainers updates the DockerInterface's Containers fields
 // with the latest information from the Docker API.
 func (di *DockerInterface) RefreshContainers(ctx context.Context) error {
 	var err error
 
 	if di.Containers, err = di.Client.ContainerList(
-		ctx, types.ContainerListOptions{All: true}); err != nil {
+		ctx, types.ContainerListOptions{
+			All: true, Filters: di.scopeFilters(filters.NewArgs())}); err != nil {
 		return &ResourceRefreshError{"container list", err}
 	}
 	return nil
 }
 
+// RefreshContainer updates the single entry of the DockerInterface's
+// Containers field for id, a container name, ID or ID prefix, without
+// listing every container. The entry is added if it is new and removed if
+// the container falls outside the interface's scope. If the container no
+// longer exists and id does not match exactly one cached entry, every
+// container is refreshed instead.
+func (di *DockerInterface) RefreshContainer(ctx context.Context, id string) error {
+	response, err := di.Client.ContainerInspect(ctx, id)
+	if client.IsErrNotFound(err) {
+		i := cachedIndex(len(di.Containers), id, func(i int) (string, string) {
+			return di.Containers[i].ID, containerName(di.Containers[i])
+		})
+		if i < 0 {
+			return di.RefreshContainers(ctx)
+		}
+
+		di.Containers = append(di.Containers[:i:i], di.Containers[i+1:]...)
+		return nil
+	} else if err != nil {
+		return &ResourceRefreshError{"container " + id, err}
+	}
+
+	containers, err := di.Client.ContainerList(ctx, types.ContainerListOptions{
+		All: true, Filters: di.scopeFilters(filters.NewArgs(filters.Arg("id", response.ID)))})
+	if err != nil {
+		return &ResourceRefreshError{"container " + id, err}
+	}
+
+	for _, container := range containers {
+		if container.ID != response.ID {
+			continue
+		}
+
+		for i := range di.Containers {
+			if di.Containers[i].ID == container.ID {
+				di.Containers[i] = container
+				return nil
+			}
+		}
+		di.Containers = append([]types.Container{container}, di.Containers...)
+		return nil
+	}
+
+	for i := range di.Containers {
+		if di.Containers[i].ID == response.ID {
+			di.Containers = append(di.Containers[:i:i], di.Containers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// cachedIndex returns the index of the one cached resource, out of n, that
+// id names. id may be the resource's ID, its name, or a unique ID prefix, as
+// the daemon accepts. resource returns the ID and name at an index. -1 is
+// returned if no resource or more than one matches.
+func cachedIndex(n int, id string, resource func(int) (string, string)) int {
+	prefixed := -1
+
+	for i := 0; i < n; i++ {
+		resourceID, name := resource(i)
+
+		switch {
+		case resourceID == id || name == id:
+			return i
+		case strings.HasPrefix(resourceID, id) && prefixed == -1:
+			prefixed = i
+		case strings.HasPrefix(resourceID, id):
+			prefixed = -2
+		}
+	}
+
+	if prefixed < 0 || id == "" {
+		return -1
+	}
+	return prefixed
+}
+
 // RefreshImages updates the DockerInterface's Images field with the
 // latest information from the Docker API.
 func (di *DockerInterface) RefreshImages(ctx context.Context) error {
 	var err error
 
 	if di.Images, err = di.Client.ImageList(
-		ctx, types.ImageListOptions{All: true}); err != nil {
+		ctx, types.ImageListOptions{
+			All: true, Filters: di.scopeFilters(filters.NewArgs())}); err != nil {
 		return &ResourceRefreshError{"image list", err}
 	}
 	return nil
@@ -79,19 +191,68 @@ func (di *DockerInterface) RefreshNetworks(ctx context.Context) error {
 	var err error
 
 	if di.Networks, err = di.Client.NetworkList(
-		ctx, types.NetworkListOptions{}); err != nil {
+		ctx, types.NetworkListOptions{
+			Filters: di.scopeFilters(filters.NewArgs())}); err != nil {
 		return &ResourceRefreshError{"network list", err}
 	}
 	return nil
 }
 
+// RefreshNetwork updates the single entry of the DockerInterface's
+// Networks field for id, a network name, ID or ID prefix, by inspecting
+// only that network. Like the entries from RefreshNetworks, it does not list
+// the network's containers; use Topology for those. The entry is added if
+// it is new and removed if the network falls outside the interface's scope.
+// If the network no longer exists and id does not match exactly one cached
+// entry, every network is refreshed instead.
+func (di *DockerInterface) RefreshNetwork(ctx context.Context, id string) error {
+	response, err := di.Client.NetworkInspect(ctx, id, types.NetworkInspectOptions{})
+	if client.IsErrNotFound(err) {
+		i := cachedIndex(len(di.Networks), id, func(i int) (string, string) {
+			return di.Networks[i].ID, di.Networks[i].Name
+		})
+		if i < 0 {
+			return di.RefreshNetworks(ctx)
+		}
+
+		di.Networks = append(di.Networks[:i:i], di.Networks[i+1:]...)
+		return nil
+	} else if err != nil {
+		return &ResourceRefreshError{"network " + id, err}
+	}
+
+	// Match the shape of NetworkList entries, which omit these.
+	response.Containers = map[string]types.EndpointResource{}
+	response.Services = nil
+
+	inScope := di.scope.MatchKVList("label", response.Labels)
+	for i := range di.Networks {
+		if di.Networks[i].ID != response.ID {
+			continue
+		}
+
+		if inScope {
+			di.Networks[i] = response
+		} else {
+			di.Networks = append(di.Networks[:i:i], di.Networks[i+1:]...)
+		}
+		return nil
+	}
+
+	if inScope {
+		di.Networks = append(di.Networks, response)
+	}
+	return nil
+}
+
 // RefreshVolumes updates the DockerInterface's Volumes field with the
 // latest information from the Docker API.
 func (di *DockerInterface) RefreshVolumes(ctx context.Context) error {
 	var err error
 	var volumeBody volume.VolumeListOKBody
 
-	if volumeBody, err = di.Client.VolumeList(ctx, filters.Args{}); err != nil {
+	if volumeBody, err = di.Client.VolumeList(
+		ctx, di.scopeFilters(filters.NewArgs())); err != nil {
 		return &ResourceRefreshError{"volume list", err}
 	}
 
@@ -99,11 +260,52 @@ func (di *DockerInterface) RefreshVolumes(ctx context.Context) error {
 	return nil
 }
 
+// refreshResources updates the Containers, Images, Networks and Volumes
+// fields with the latest information from the Docker API.
+func (di *DockerInterface) refreshResources(ctx context.Context) error {
+	if err := di.RefreshContainers(ctx); err != nil {
+		return err
+	}
+
+	if err := di.RefreshImages(ctx); err != nil {
+		return err
+	}
+
+	if err := di.RefreshNetworks(ctx); err != nil {
+		return err
+	}
+	return di.RefreshVolumes(ctx)
+}
+
+// Scoped returns a DockerInterface sharing di's client that only tracks
+// containers, images, networks, and volumes matching every label selector.
+// A selector is either a label key or a key=value pair, for example
+// "com.example.team=payments". Resources created through the scoped
+// interface must carry the selected labels to appear in its lists.
+func (di *DockerInterface) Scoped(ctx context.Context,
+	selectors ...string) (*DockerInterface, error) {
+	scoped := &DockerInterface{
+		Client: di.Client,
+		Info:   di.Info,
+		scope:  di.scopeFilters(filters.NewArgs()),
+		auths:  di.auths,
+	}
+
+	for _, selector := range selectors {
+		scoped.scope.Add("label", selector)
+	}
+
+	if err := scoped.refreshResources(ctx); err != nil {
+		return nil, err
+	}
+	return scoped, nil
+}
+
 // NewInterface returns a DockerInterface with information about the
 // Docker daemon and all containers, images, networks, and volumes.
 func NewInterface(ctx context.Context) (*DockerInterface, error) {
 	var err error
-	di := &DockerInterface{}
+	di := &DockerInterface{scope: filters.NewArgs()}
 
 	di.Client, err = client.NewClientWithOpts(
 		client.FromEnv, client.WithAPIVersionNegotiation())
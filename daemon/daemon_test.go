@@ -0,0 +1,177 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// Start an Engine API stand-in serving the given containers and networks by
+// ID, and any volume by name, and return an interface connected to it with
+// an empty cache.
+func newTestEngineInterface(t *testing.T, containers map[string]types.Container,
+	networks map[string]types.NetworkResource) *DockerInterface {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+		switch {
+		case len(parts) == 3 && parts[1] == "containers" && parts[2] == "json":
+			args, _ := filters.FromJSON(r.URL.Query().Get("filters"))
+			list := []types.Container{}
+			for id, container := range containers {
+				if !args.Contains("id") || args.ExactMatch("id", id) {
+					list = append(list, container)
+				}
+			}
+			json.NewEncoder(w).Encode(list)
+		case len(parts) == 4 && parts[1] == "containers" && parts[3] == "json":
+			container, ok := containers[parts[2]]
+			if !ok {
+				http.Error(w, `{"message": "no such container"}`, http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{ID: container.ID}})
+		case len(parts) == 2 && parts[1] == "networks":
+			list := []types.NetworkResource{}
+			for _, network := range networks {
+				network.Containers = map[string]types.EndpointResource{}
+				list = append(list, network)
+			}
+			json.NewEncoder(w).Encode(list)
+		case len(parts) == 3 && parts[1] == "networks":
+			network, ok := networks[parts[2]]
+			if !ok {
+				http.Error(w, `{"message": "no such network"}`, http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(network)
+		case len(parts) == 3 && parts[1] == "volumes":
+			json.NewEncoder(w).Encode(types.Volume{Name: parts[2], Driver: "local"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+server.Listener.Addr().String()),
+		client.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("got error creating client: %s", err)
+	}
+	return &DockerInterface{Client: cli, scope: filters.NewArgs()}
+}
+
+// TestRefreshContainer
+func TestRefreshContainer(t *testing.T) {
+	ctx := context.TODO()
+	containers := map[string]types.Container{
+		"c1": {ID: "c1", Names: []string{"/web"}, State: "running"},
+	}
+	di := newTestEngineInterface(t, containers, nil)
+	di.Containers = []types.Container{{ID: "c1", State: "created"}, {ID: "c2", Names: []string{"/gone"}}}
+
+	if err := di.RefreshContainer(ctx, "c1"); err != nil {
+		t.Logf("got error refreshing container: %s", err)
+		t.FailNow()
+	}
+	if len(di.Containers) != 2 || di.Containers[0].State != "running" {
+		t.Errorf("got containers %+v, want c1 running", di.Containers)
+	}
+
+	if err := di.RefreshContainer(ctx, "gone"); err != nil {
+		t.Logf("got error refreshing removed container: %s", err)
+		t.FailNow()
+	}
+	if len(di.Containers) != 1 || di.Containers[0].ID != "c1" {
+		t.Errorf("got containers %+v, want only c1", di.Containers)
+	}
+
+	// A removed container given by ID prefix is dropped from the cache.
+	di.Containers = append(di.Containers, types.Container{ID: "abc123"})
+	di.RefreshContainer(ctx, "abc")
+	if len(di.Containers) != 1 {
+		t.Errorf("got containers %+v, want removed abc123 dropped", di.Containers)
+	}
+
+	// An ambiguous prefix falls back to listing every container.
+	di.Containers = append(di.Containers, types.Container{ID: "ab1"}, types.Container{ID: "ab2"})
+	di.RefreshContainer(ctx, "ab")
+	if len(di.Containers) != 1 || di.Containers[0].ID != "c1" {
+		t.Errorf("got containers %+v, want full refresh", di.Containers)
+	}
+}
+
+// TestScopeLabels
+func TestScopeLabels(t *testing.T) {
+	di := &DockerInterface{scope: filters.NewArgs(
+		filters.Arg("label", "team=payments"), filters.Arg("label", "managed"))}
+
+	labels := di.scopeLabels()
+	if len(labels) != 2 || labels["team"] != "payments" || labels["managed"] != "" {
+		t.Errorf("got labels %v, want team=payments and managed", labels)
+	}
+}
+
+// TestCachedIndex
+func TestCachedIndex(t *testing.T) {
+	resources := [][2]string{{"abc123", "web"}, {"abd456", "db"}, {"fff000", "abc"}}
+	resource := func(i int) (string, string) { return resources[i][0], resources[i][1] }
+
+	tables := []struct {
+		id   string
+		want int
+	}{
+		{"abc123", 0},
+		{"db", 1},
+		{"abc", 2},
+		{"abd", 1},
+		{"ab", -1},
+		{"zzz", -1},
+		{"", -1},
+	}
+
+	for _, table := range tables {
+		if got := cachedIndex(len(resources), table.id, resource); got != table.want {
+			t.Errorf("got index %d for %q, want %d", got, table.id, table.want)
+		}
+	}
+}
+
+// TestRefreshNetwork
+func TestRefreshNetwork(t *testing.T) {
+	ctx := context.TODO()
+	networks := map[string]types.NetworkResource{
+		"n1": {ID: "n1", Name: "frontend", Labels: map[string]string{"team": "web"},
+			Containers: map[string]types.EndpointResource{"c1": {Name: "web"}}},
+		"n2": {ID: "n2", Name: "backend"},
+	}
+	di := newTestEngineInterface(t, nil, networks)
+	di.Networks = []types.NetworkResource{{ID: "n1", Name: "frontend"}, {ID: "n3", Name: "old"}}
+
+	for _, id := range []string{"n1", "n2", "old"} {
+		if err := di.RefreshNetwork(ctx, id); err != nil {
+			t.Logf("got error refreshing network %s: %s", id, err)
+			t.FailNow()
+		}
+	}
+	if len(di.Networks) != 2 || di.Networks[0].Labels["team"] != "web" || di.Networks[1].ID != "n2" {
+		t.Errorf("got networks %+v, want refreshed n1 and new n2", di.Networks)
+	}
+	if len(di.Networks[0].Containers) != 0 {
+		t.Errorf("got containers %v, want network list shape", di.Networks[0].Containers)
+	}
+
+	di.scope.Add("label", "team=web")
+	di.RefreshNetwork(ctx, "n2")
+	if len(di.Networks) != 1 || di.Networks[0].ID != "n1" {
+		t.Errorf("got networks %+v, want out-of-scope n2 removed", di.Networks)
+	}
+}
@@ -0,0 +1,314 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// DefaultProbeTimeout is how long a connectivity probe may take when
+// DiagnoseOptions.Timeout is zero.
+const DefaultProbeTimeout = 3 * time.Second
+
+// probeUnavailable is the exit code of a probe whose tools are missing from
+// the source container's image.
+const probeUnavailable = 127
+
+// probeTimedOut is the exit code reported for a probe that did not finish
+// within its timeout.
+const probeTimedOut = -1
+
+// Shell scripts run inside the source container by the connectivity
+// probes. Each falls back between common tools and exits with
+// probeUnavailable if none are present.
+const (
+	dnsProbeScript = `if command -v getent >/dev/null; then exec getent hosts "$0"; ` +
+		`elif command -v nslookup >/dev/null; then exec nslookup "$0"; else exit 127; fi`
+	tcpProbeScript = `if command -v nc >/dev/null; then exec nc -z -w "$2" "$0" "$1"; ` +
+		`elif command -v bash >/dev/null; then exec timeout "$2" bash -c 'exec 3<>"/dev/tcp/$0/$1"' "$0" "$1"; ` +
+		`else exit 127; fi`
+)
+
+// Reasons reported by DiagnoseConnectivity for a failed check.
+const (
+	ReasonSourceNotRunning = "source not running"
+	ReasonTargetNotRunning = "target not running"
+	ReasonNoSharedNetwork  = "no shared network"
+	ReasonNoDNS            = "no DNS on shared network"
+	ReasonNameNotResolved  = "name not resolved"
+	ReasonNoAddress        = "no address on shared network"
+	ReasonPortNotListening = "port not listening"
+	ReasonProbeUnavailable = "probe unavailable"
+)
+
+// DiagnoseOptions controls which probes DiagnoseConnectivity runs from
+// inside the source container.
+type DiagnoseOptions struct {
+	// DNS looks up the target's name from the source container.
+	DNS bool
+	// TCP connects to the target's port from the source container.
+	TCP bool
+	// Timeout overrides DefaultProbeTimeout. It bounds each probe as a
+	// whole, including the exec calls to the daemon.
+	Timeout time.Duration
+}
+
+// ConnectivityProblem explains why one check failed.
+type ConnectivityProblem struct {
+	Reason string
+	Detail string
+}
+
+// String is called to describe a problem in a single line.
+func (cp ConnectivityProblem) String() string {
+	if cp.Detail == "" {
+		return cp.Reason
+	}
+	return cp.Reason + ": " + cp.Detail
+}
+
+// ProbeResult holds the outcome of a command run inside the source
+// container.
+type ProbeResult struct {
+	Command  []string
+	ExitCode int
+	Output   string
+}
+
+// OK reports whether the probe succeeded.
+func (pr ProbeResult) OK() bool {
+	return pr.ExitCode == 0
+}
+
+// ConnectivityReport holds the result of diagnosing connectivity from one
+// container to another.
+type ConnectivityReport struct {
+	From           TopologyContainer
+	To             TopologyContainer
+	Port           int
+	SharedNetworks []string
+	// Addresses maps each shared network to the target's address on it.
+	Addresses map[string]string
+	// Aliases holds the names the target is reachable by on the shared
+	// networks that provide DNS.
+	Aliases  []string
+	DNS      *ProbeResult
+	TCP      *ProbeResult
+	Problems []ConnectivityProblem
+}
+
+// OK reports whether every check passed.
+func (cr ConnectivityReport) OK() bool {
+	return len(cr.Problems) == 0
+}
+
+// tcpAddress returns the target's address on the first shared network that
+// gives it one.
+func (cr ConnectivityReport) tcpAddress() (string, bool) {
+	for _, network := range cr.SharedNetworks {
+		if address := cr.Addresses[network]; address != "" {
+			return address, true
+		}
+	}
+	return "", false
+}
+
+// addProblem records a failed check.
+func (cr *ConnectivityReport) addProblem(reason string, format string, args ...interface{}) {
+	cr.Problems = append(cr.Problems, ConnectivityProblem{reason, fmt.Sprintf(format, args...)})
+}
+
+// newConnectivityReport checks, without contacting the daemon, whether two
+// containers are running and share a network, and collects the target's
+// addresses and aliases on the shared networks. The default bridge network
+// shares addresses but provides no DNS.
+func newConnectivityReport(from, to TopologyContainer, port int) ConnectivityReport {
+	report := ConnectivityReport{From: from, To: to, Port: port, Addresses: make(map[string]string)}
+
+	if from.State != "running" {
+		report.addProblem(ReasonSourceNotRunning, "%s is %s", from.Name, from.State)
+	}
+	if to.State != "running" {
+		report.addProblem(ReasonTargetNotRunning, "%s is %s", to.Name, to.State)
+	}
+
+	dns := false
+	for _, target := range to.Endpoints {
+		for _, source := range from.Endpoints {
+			if source.NetworkID != target.NetworkID || target.Network == "none" {
+				continue
+			}
+
+			address := target.IPv4Address
+			switch {
+			case target.Network == "host":
+				address = "127.0.0.1"
+			case address == "":
+				address = target.IPv6Address
+			}
+
+			report.SharedNetworks = append(report.SharedNetworks, target.Network)
+			report.Addresses[target.Network] = address
+
+			if target.Network != "bridge" && target.Network != "host" {
+				dns = true
+				for _, alias := range target.Aliases {
+					if !containsString(report.Aliases, alias) {
+						report.Aliases = append(report.Aliases, alias)
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(report.SharedNetworks) == 0:
+		report.addProblem(ReasonNoSharedNetwork, "%s and %s have no network in common",
+			from.Name, to.Name)
+	case dns:
+		report.Aliases = append([]string{to.Name}, report.Aliases...)
+	}
+	return report
+}
+
+// execProbe runs cmd inside a running container and returns its exit code
+// and combined output. A probe still running after timeout is reported with
+// the exit code probeTimedOut rather than as an error.
+func (di *DockerInterface) execProbe(ctx context.Context,
+	id string, cmd []string, timeout time.Duration) (ProbeResult, error) {
+	result := ProbeResult{Command: cmd}
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	failed := func(err error) (ProbeResult, error) {
+		if probeCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			result.ExitCode = probeTimedOut
+			result.Output = fmt.Sprintf("timed out after %s", timeout)
+			return result, nil
+		}
+		return result, fmt.Errorf("failed to run probe: %s", err)
+	}
+
+	exec, err := di.Client.ContainerExecCreate(probeCtx, id, types.ExecConfig{
+		Cmd: cmd, AttachStdout: true, AttachStderr: true})
+	if err != nil {
+		return failed(err)
+	}
+
+	attach, err := di.Client.ContainerExecAttach(probeCtx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return failed(err)
+	}
+	defer attach.Close()
+
+	// Closing the connection unblocks the copy below once the probe times out.
+	go func() {
+		<-probeCtx.Done()
+		attach.Close()
+	}()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, attach.Reader); err != nil {
+		return failed(err)
+	}
+
+	inspect, err := di.Client.ContainerExecInspect(probeCtx, exec.ID)
+	if err != nil {
+		return failed(err)
+	}
+
+	result.ExitCode, result.Output = inspect.ExitCode, output.String()
+	return result, nil
+}
+
+// DiagnoseConnectivity explains whether the container from can reach port
+// on the container to. Both containers may be given as a name or ID. It
+// checks the cached topology for a shared network and resolves the target's
+// addresses and aliases, then optionally looks up the target's name and
+// connects to its port from inside the source container, giving each probe
+// opts.Timeout to finish. Failed checks are
+// listed in the report's Problems; an error is only returned if a container
+// cannot be found or a probe cannot be run.
+func (di *DockerInterface) DiagnoseConnectivity(ctx context.Context,
+	from string, to string, port int, opts DiagnoseOptions) (ConnectivityReport, error) {
+	var containers [2]TopologyContainer
+
+	for i, input := range []string{from, to} {
+		container, err := di.ResolveContainer(input)
+		if err != nil {
+			return ConnectivityReport{}, err
+		}
+
+		if err := di.RefreshContainer(ctx, container.ID); err != nil {
+			return ConnectivityReport{}, err
+		}
+
+		found := false
+		for _, tc := range di.Topology().Containers {
+			if tc.ID == container.ID {
+				containers[i], found = tc, true
+			}
+		}
+		if !found {
+			return ConnectivityReport{}, &ResourceNotFoundError{KindContainer, input}
+		}
+	}
+
+	report := newConnectivityReport(containers[0], containers[1], port)
+	if !report.OK() {
+		return report, nil
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultProbeTimeout
+	}
+	seconds := strconv.Itoa(int((timeout + time.Second - 1) / time.Second))
+
+	if opts.DNS && len(report.Aliases) == 0 {
+		report.addProblem(ReasonNoDNS, "%s and %s only share %v",
+			report.From.Name, report.To.Name, report.SharedNetworks)
+	} else if opts.DNS {
+		result, err := di.execProbe(ctx, report.From.ID,
+			[]string{"sh", "-c", dnsProbeScript, report.To.Name}, timeout)
+		if err != nil {
+			return report, err
+		}
+		report.DNS = &result
+
+		switch {
+		case result.ExitCode == probeUnavailable:
+			report.addProblem(ReasonProbeUnavailable, "no getent or nslookup in %s", report.From.Name)
+		case !result.OK():
+			report.addProblem(ReasonNameNotResolved, "%s cannot resolve %s", report.From.Name, report.To.Name)
+		}
+	}
+
+	address, ok := report.tcpAddress()
+	if opts.TCP && port > 0 && !ok {
+		report.addProblem(ReasonNoAddress, "%s has no address on %v",
+			report.To.Name, report.SharedNetworks)
+	} else if opts.TCP && port > 0 {
+		result, err := di.execProbe(ctx, report.From.ID,
+			[]string{"sh", "-c", tcpProbeScript, address, strconv.Itoa(port), seconds}, timeout)
+		if err != nil {
+			return report, err
+		}
+		report.TCP = &result
+
+		switch {
+		case result.ExitCode == probeUnavailable:
+			report.addProblem(ReasonProbeUnavailable, "no nc or bash in %s", report.From.Name)
+		case !result.OK():
+			report.addProblem(ReasonPortNotListening, "%s:%d on %s did not accept a connection",
+				address, port, report.To.Name)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestNewConnectivityReport
+func TestNewConnectivityReport(t *testing.T) {
+	topology := newTestTopologyInterface().Topology()
+	containers := make(map[string]TopologyContainer)
+	for _, tc := range topology.Containers {
+		containers[tc.Name] = tc
+	}
+
+	report := newConnectivityReport(containers["web"], containers["proxy"], 80)
+	if !report.OK() {
+		t.Errorf("got problems %v, want none", report.Problems)
+	}
+	if !reflect.DeepEqual(report.SharedNetworks, []string{"frontend"}) ||
+		report.Addresses["frontend"] != "10.1.0.3" {
+		t.Errorf("got shared networks %v and addresses %v", report.SharedNetworks, report.Addresses)
+	}
+	if !reflect.DeepEqual(report.Aliases, []string{"proxy", "lb"}) {
+		t.Errorf("got aliases %v, want [proxy lb]", report.Aliases)
+	}
+
+	tables := []struct {
+		from, to string
+		want     []string
+	}{
+		{"web", "db", []string{ReasonTargetNotRunning, ReasonNoSharedNetwork}},
+		{"db", "web", []string{ReasonSourceNotRunning, ReasonNoSharedNetwork}},
+	}
+
+	for _, table := range tables {
+		report := newConnectivityReport(containers[table.from], containers[table.to], 80)
+
+		var got []string
+		for _, problem := range report.Problems {
+			got = append(got, problem.Reason)
+		}
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("got problems %v from %s to %s, want %v", report.Problems,
+				table.from, table.to, table.want)
+		}
+	}
+}
+
+// TestTCPAddress
+func TestTCPAddress(t *testing.T) {
+	report := ConnectivityReport{SharedNetworks: []string{"jobs", "backend"},
+		Addresses: map[string]string{"jobs": "", "backend": "10.2.0.4"}}
+
+	if address, ok := report.tcpAddress(); !ok || address != "10.2.0.4" {
+		t.Errorf("got address %q, want 10.2.0.4 on backend", address)
+	}
+
+	report.Addresses["backend"] = ""
+	if address, ok := report.tcpAddress(); ok {
+		t.Errorf("got address %q, want none", address)
+	}
+}
+
+// TestDiagnoseConnectivity
+func TestDiagnoseConnectivity(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	netID, _ := di.NewNetwork(ctx, map[string]string{"name": "test_network"})
+	defer di.RemoveNetwork(ctx, netID)
+
+	var ids []string
+	for _, name := range []string{"test_client", "test_server"} {
+		id, _ := di.NewContainer(ctx, map[string]string{"name": name, "image": "nginx"})
+		defer di.RemoveContainer(ctx, id)
+		di.ConnectNetwork(ctx, netID, id)
+		di.StartContainer(ctx, id)
+		ids = append(ids, id)
+	}
+
+	opts := DiagnoseOptions{DNS: true, TCP: true}
+	report, err := di.DiagnoseConnectivity(ctx, "test_client", "test_server", 80, opts)
+	if err != nil {
+		t.Logf("got error diagnosing connectivity: %s", err)
+		t.FailNow()
+	}
+	if !report.OK() || report.DNS == nil || report.TCP == nil {
+		t.Errorf("got problems %v, want reachable server", report.Problems)
+	}
+
+	report, _ = di.DiagnoseConnectivity(ctx, "test_client", "test_server", 81, opts)
+	if len(report.Problems) != 1 || report.Problems[0].Reason != ReasonPortNotListening {
+		t.Errorf("got problems %v, want port not listening", report.Problems)
+	}
+
+	// Both containers remain on the default bridge, which has no DNS.
+	di.DisconnectNetwork(ctx, netID, ids[1])
+	report, _ = di.DiagnoseConnectivity(ctx, "test_client", "test_server", 80, opts)
+	if len(report.Problems) != 1 || report.Problems[0].Reason != ReasonNoDNS {
+		t.Errorf("got problems %v, want no DNS on the default bridge", report.Problems)
+	}
+
+	di.DisconnectNetwork(ctx, "bridge", ids[1])
+	report, _ = di.DiagnoseConnectivity(ctx, "test_client", "test_server", 80, opts)
+	if len(report.Problems) != 1 || report.Problems[0].Reason != ReasonNoSharedNetwork {
+		t.Errorf("got problems %v, want no shared network", report.Problems)
+	}
+}
@@ -1,35 +1,216 @@
 package daemon
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/registry"
 )
 
-// MaxImageResults represents the maximum number of results from a search.
-var MaxImageResults int = 10
+// DefaultSearchLimit is the maximum number of results from a search when
+// SearchOptions.Limit is not set.
+const DefaultSearchLimit = 10
 
-// PullImage pulls the image with the given img name.
+// SearchOptions controls which results are returned from a search.
+type SearchOptions struct {
+	// Limit is the maximum number of results, up to 100.
+	Limit int
+	// Official and Automated, if set, only return images that are or are
+	// not official or automated builds.
+	Official  *bool
+	Automated *bool
+	// MinStars only returns images with at least this many stars.
+	MinStars int
+}
+
+// filters returns the daemon-side filters for the search.
+func (opts SearchOptions) filters() filters.Args {
+	args := filters.NewArgs()
+
+	addBoolFilter(args, "is-official", opts.Official)
+	addBoolFilter(args, "is-automated", opts.Automated)
+	if opts.MinStars > 0 {
+		args.Add("stars", strconv.Itoa(opts.MinStars))
+	}
+	return args
+}
+
+// PullOptions controls how an image is pulled.
+type PullOptions struct {
+	// Platform selects a platform such as "linux/arm64" for multi-platform
+	// images. An empty platform uses the daemon's own.
+	Platform string
+	// Progress, if set, is called for every message in the pull stream.
+	Progress ProgressFunc
+}
+
+// PullImage pulls the image with the given img name for the daemon's own
+// platform, as PullImageWithOptions does with default options.
 func (di *DockerInterface) PullImage(ctx context.Context, img string) error {
-	response, err := di.Client.ImagePull(ctx, img, types.ImagePullOptions{})
+	return di.PullImageWithOptions(ctx, img, PullOptions{})
+}
 
+// PullImageWithOptions pulls the image with the given img name, sending any
+// credentials found for its registry. Errors reported by the daemon during
+// the pull are returned as an *ImageStreamError.
+func (di *DockerInterface) PullImageWithOptions(ctx context.Context,
+	img string, opts PullOptions) error {
+	auth, err := di.registryAuth(imageRegistry(img))
 	if err != nil {
 		return fmt.Errorf("failed to pull image: %s", err)
 	}
 
-	scan := bufio.NewScanner(response)
-	for scan.Scan() {
-	}
+	response, err := di.Client.ImagePull(ctx, img, types.ImagePullOptions{
+		Platform:     opts.Platform,
+		RegistryAuth: auth,
+	})
 
-	if err := scan.Err(); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to pull image: %s", err)
 	}
+	defer response.Close()
+
+	tracker := newProgressTracker(opts.Progress)
+	if err := decodeStream(response, "pull", tracker.update); err != nil {
+		return err
+	}
+	return di.RefreshImages(ctx)
+}
+
+// PushOptions controls how an image is pushed.
+type PushOptions struct {
+	// Progress, if set, is called for every message in the push stream.
+	Progress ProgressFunc
+}
+
+// pushResult is the auxiliary message sent by the daemon once a push
+// completes.
+type pushResult struct {
+	Tag    string
+	Digest string
+	Size   int
+}
+
+// PushImage pushes ref to its registry, sending any credentials found for
+// the registry, and returns the digest of the pushed manifest. Errors
+// reported by the daemon during the push are returned as an
+// *ImageStreamError.
+func (di *DockerInterface) PushImage(ctx context.Context,
+	ref string, opts PushOptions) (string, error) {
+	auth, err := di.registryAuth(imageRegistry(ref))
+	if err != nil {
+		return "", fmt.Errorf("failed to push image: %s", err)
+	}
+	if auth == "" {
+		// The daemon rejects pushes without an auth header, even for
+		// registries that need no credentials.
+		auth, _ = encodeAuth(types.AuthConfig{})
+	}
+
+	response, err := di.Client.ImagePush(ctx, ref, types.ImagePushOptions{RegistryAuth: auth})
+	if err != nil {
+		return "", fmt.Errorf("failed to push image: %s", err)
+	}
+	defer response.Close()
+
+	var result pushResult
+	tracker := newProgressTracker(opts.Progress)
+
+	if err := decodeStream(response, "push", func(msg streamMessage) {
+		if msg.Aux != nil {
+			json.Unmarshal(*msg.Aux, &result)
+		}
+		tracker.update(msg)
+	}); err != nil {
+		return "", err
+	}
+	return result.Digest, di.RefreshImages(ctx)
+}
+
+// TagImage adds the target reference to the image referred to by source.
+func (di *DockerInterface) TagImage(ctx context.Context, source, target string) error {
+	if err := di.Client.ImageTag(ctx, source, target); err != nil {
+		return fmt.Errorf("failed to tag image: %s", err)
+	}
 	return di.RefreshImages(ctx)
 }
 
+// UntagImage removes the ref tag from its image. If ref is the image's last
+// tag the image itself is removed, as with docker rmi.
+func (di *DockerInterface) UntagImage(ctx context.Context, ref string) error {
+	if _, err := di.Client.ImageRemove(ctx, ref, types.ImageRemoveOptions{}); err != nil {
+		return fmt.Errorf("failed to untag image: %s", err)
+	}
+	return di.RefreshImages(ctx)
+}
+
+// InspectImage returns the JSON file generated by the Docker Engine for the
+// given image. id can be the ID or the image name.
+func (di *DockerInterface) InspectImage(ctx context.Context,
+	id string) (types.ImageInspect, error) {
+	response, _, err := di.Client.ImageInspectWithRaw(ctx, id)
+	if err != nil {
+		return types.ImageInspect{}, fmt.Errorf("failed to fetch image: %s", err)
+	}
+	return response, nil
+}
+
+// ImageHistory returns the history of an image, newest layer first, with
+// the command that created each layer and its size.
+func (di *DockerInterface) ImageHistory(ctx context.Context,
+	id string) ([]image.HistoryResponseItem, error) {
+	response, err := di.Client.ImageHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image history: %s", err)
+	}
+	return response, nil
+}
+
+// ImageContainerOpts returns NewContainer options pre-filled from an
+// image's configuration: its lowest exposed TCP port, cmd, entrypoint,
+// and env. Entries containing commas are quoted so NewContainer splits them
+// back into the same arguments.
+func (di *DockerInterface) ImageContainerOpts(ctx context.Context,
+	id string) (map[string]string, error) {
+	inspect, err := di.InspectImage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := map[string]string{"image": id}
+	if inspect.Config == nil {
+		return opts, nil
+	}
+
+	var ports []int
+	for port := range inspect.Config.ExposedPorts {
+		if port.Proto() == "tcp" {
+			ports = append(ports, port.Int())
+		}
+	}
+	if len(ports) > 0 {
+		sort.Ints(ports)
+		opts["port"] = strconv.Itoa(ports[0])
+	}
+
+	if len(inspect.Config.Cmd) > 0 {
+		opts["cmd"] = formatOptionList(inspect.Config.Cmd)
+	}
+	if len(inspect.Config.Entrypoint) > 0 {
+		opts["entrypoint"] = formatOptionList(inspect.Config.Entrypoint)
+	}
+	if len(inspect.Config.Env) > 0 {
+		opts["env"] = formatOptionList(inspect.Config.Env)
+	}
+	return opts, nil
+}
+
 // RemoveImage removes an image. id can be the ID or the image name.
 func (di *DockerInterface) RemoveImage(ctx context.Context, id string) error {
 	if _, err := di.Client.ImageRemove(ctx, id, types.ImageRemoveOptions{}); err != nil {
@@ -38,9 +219,25 @@ func (di *DockerInterface) RemoveImage(ctx context.Context, id string) error {
 	return di.RefreshImages(ctx)
 }
 
-// SearchImage searches the registry for the given image.
-func (di *DockerInterface) SearchImage(ctx context.Context, image string) ([]registry.SearchResult, error) {
-	results, err := di.Client.ImageSearch(ctx, image, types.ImageSearchOptions{Limit: MaxImageResults})
+// SearchImage searches the registry for the given image, sending any
+// credentials found for that registry.
+func (di *DockerInterface) SearchImage(ctx context.Context,
+	image string, opts SearchOptions) ([]registry.SearchResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	auth, err := di.registryAuth(searchRegistry(image))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search image: %s", err)
+	}
+
+	results, err := di.Client.ImageSearch(ctx, image, types.ImageSearchOptions{
+		Limit:        limit,
+		Filters:      opts.filters(),
+		RegistryAuth: auth,
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to search image: %s", err)
@@ -22,6 +22,28 @@ func TestPullImage(t *testing.T) {
 	}
 }
 
+// TestPullImageProgress
+func TestPullImageProgress(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	var last Progress
+
+	opts := PullOptions{Progress: func(p Progress) { last = p }}
+	if err := di.PullImageWithOptions(ctx, "busybox", opts); err != nil {
+		t.Logf("got error pulling image: %s", err)
+		t.FailNow()
+	}
+	defer di.RemoveImage(ctx, "busybox")
+
+	if last.Status == "" {
+		t.Error("got no progress updates")
+	}
+
+	if err := di.PullImage(ctx, "busybox:no_such_tag"); err == nil {
+		t.Error("expected error pulling missing tag")
+	}
+}
+
 // TestRemoveImage
 func TestRemoveImage(t *testing.T) {
 	ctx := context.TODO()
@@ -42,9 +64,9 @@ func TestRemoveImage(t *testing.T) {
 func TestSearchImages(t *testing.T) {
 	ctx := context.TODO()
 	di, _ := NewInterface(ctx)
-	want := MaxImageResults
+	want := DefaultSearchLimit
 
-	results, err := di.SearchImage(ctx, "busybox")
+	results, err := di.SearchImage(ctx, "busybox", SearchOptions{})
 
 	if err != nil {
 		t.Logf("got error searching images: %s", err)
@@ -54,4 +76,98 @@ func TestSearchImages(t *testing.T) {
 	if len(results) != want {
 		t.Errorf("got %d images in search, want %d", len(results), want)
 	}
+
+	official := true
+	results, err = di.SearchImage(ctx, "busybox", SearchOptions{
+		Limit: 5, Official: &official, MinStars: 100})
+	if err != nil {
+		t.Logf("got error searching images: %s", err)
+		t.FailNow()
+	}
+
+	for _, result := range results {
+		if !result.IsOfficial || result.StarCount < 100 {
+			t.Errorf("got result %s not matching filters", result.Name)
+		}
+	}
+}
+
+// TestTagImage
+func TestTagImage(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	di.PullImage(ctx, "busybox")
+	defer di.RemoveImage(ctx, "busybox")
+
+	if err := di.TagImage(ctx, "busybox", "dockland/busybox:test"); err != nil {
+		t.Logf("got error tagging image: %s", err)
+		t.FailNow()
+	}
+	if _, err := di.ResolveImage("dockland/busybox:test"); err != nil {
+		t.Errorf("got error finding tagged image: %s", err)
+	}
+
+	if err := di.UntagImage(ctx, "dockland/busybox:test"); err != nil {
+		t.Errorf("got error untagging image: %s", err)
+	}
+	if _, err := di.ResolveImage("busybox"); err != nil {
+		t.Errorf("got error finding original image after untag: %s", err)
+	}
+}
+
+// TestPushImage
+func TestPushImage(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	if _, err := di.PushImage(ctx, "localhost:1/dockland/no_such_image", PushOptions{}); err == nil {
+		t.Error("expected error pushing missing image")
+	}
+}
+
+// TestInspectImage
+func TestInspectImage(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	di.PullImage(ctx, "nginx")
+
+	inspect, err := di.InspectImage(ctx, "nginx")
+	if err != nil {
+		t.Logf("got error inspecting image: %s", err)
+		t.FailNow()
+	}
+	if len(inspect.Config.ExposedPorts) == 0 {
+		t.Error("got no exposed ports for nginx")
+	}
+
+	opts, err := di.ImageContainerOpts(ctx, "nginx")
+	if err != nil {
+		t.Logf("got error reading container options: %s", err)
+		t.FailNow()
+	}
+	if opts["port"] != "80" {
+		t.Errorf("got port %s, want 80", opts["port"])
+	}
+	if _, err := di.InspectImage(ctx, "no_such_image"); err == nil {
+		t.Error("expected error inspecting image")
+	}
+}
+
+// TestImageHistory
+func TestImageHistory(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	di.PullImage(ctx, "nginx")
+
+	history, err := di.ImageHistory(ctx, "nginx")
+	if err != nil {
+		t.Logf("got error fetching history: %s", err)
+		t.FailNow()
+	}
+	if len(history) == 0 {
+		t.Error("got no history for nginx")
+	}
 }
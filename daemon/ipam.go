@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+)
+
+// SubnetOverlapError is returned when a requested subnet overlaps a subnet
+// already in use, either by an existing network or by another requested
+// subnet of the same network.
+type SubnetOverlapError struct {
+	Subnet        string
+	Network       string
+	NetworkSubnet string
+}
+
+// Error is called when a network cannot be created because its subnet
+// overlaps another.
+func (s *SubnetOverlapError) Error() string {
+	return fmt.Sprintf("subnet %s overlaps subnet %s of network %s",
+		s.Subnet, s.NetworkSubnet, s.Network)
+}
+
+// parseSubnet parses a subnet in CIDR notation.
+func parseSubnet(subnet string) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subnet %s: %s", subnet, err)
+	}
+	return ipNet, nil
+}
+
+// subnetsOverlap reports whether two subnets share any addresses.
+func subnetsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// findPool returns the index of the pool whose subnet contains ip.
+func findPool(subnets []*net.IPNet, ip net.IP) int {
+	for i, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return i
+		}
+	}
+	return -1
+}
+
+// newIPAMPools builds one IPAM config per subnet, matching each gateway,
+// IP range and auxiliary address to the subnet that contains it, as
+// docker network create does. Subnets may be IPv4 or IPv6.
+func newIPAMPools(subnets, ipRanges, gateways []string,
+	auxAddresses map[string]string) ([]network.IPAMConfig, error) {
+	pools := make([]network.IPAMConfig, len(subnets))
+	nets := make([]*net.IPNet, len(subnets))
+
+	for i, subnet := range subnets {
+		ipNet, err := parseSubnet(subnet)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < i; j++ {
+			if subnetsOverlap(ipNet, nets[j]) {
+				return nil, &SubnetOverlapError{subnet, "being created", subnets[j]}
+			}
+		}
+		pools[i].Subnet, nets[i] = ipNet.String(), ipNet
+	}
+
+	for _, ipRange := range ipRanges {
+		rangeNet, err := parseSubnet(ipRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range %s: %s", ipRange, err)
+		}
+
+		i := findPool(nets, rangeNet.IP)
+		switch {
+		case i < 0:
+			return nil, fmt.Errorf("IP range %s is not in any subnet", ipRange)
+		case pools[i].IPRange != "":
+			return nil, fmt.Errorf("subnet %s has more than one IP range", pools[i].Subnet)
+		}
+		pools[i].IPRange = rangeNet.String()
+	}
+
+	for _, gateway := range gateways {
+		ip := net.ParseIP(gateway)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid gateway %s", gateway)
+		}
+
+		i := findPool(nets, ip)
+		switch {
+		case i < 0:
+			return nil, fmt.Errorf("gateway %s is not in any subnet", gateway)
+		case pools[i].Gateway != "":
+			return nil, fmt.Errorf("subnet %s has more than one gateway", pools[i].Subnet)
+		}
+		pools[i].Gateway = ip.String()
+	}
+
+	for host, address := range auxAddresses {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid aux address %s=%s", host, address)
+		}
+
+		i := findPool(nets, ip)
+		if i < 0 {
+			return nil, fmt.Errorf("aux address %s=%s is not in any subnet", host, address)
+		}
+
+		if pools[i].AuxAddress == nil {
+			pools[i].AuxAddress = make(map[string]string)
+		}
+		pools[i].AuxAddress[host] = ip.String()
+	}
+	return pools, nil
+}
+
+// checkSubnetOverlap returns a *SubnetOverlapError if any of pools overlaps
+// a subnet of the existing networks.
+func checkSubnetOverlap(pools []network.IPAMConfig, existing []types.NetworkResource) error {
+	for _, pool := range pools {
+		ipNet, err := parseSubnet(pool.Subnet)
+		if err != nil {
+			return err
+		}
+
+		for _, resource := range existing {
+			for _, config := range resource.IPAM.Config {
+				existingNet, err := parseSubnet(config.Subnet)
+				if err != nil {
+					continue
+				}
+
+				if subnetsOverlap(ipNet, existingNet) {
+					return &SubnetOverlapError{pool.Subnet, resource.Name, config.Subnet}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+)
+
+// TestNewIPAMPools
+func TestNewIPAMPools(t *testing.T) {
+	pools, err := newIPAMPools(
+		[]string{"172.28.0.0/16", "fd00:28::/64"},
+		[]string{"172.28.5.0/24"},
+		[]string{"fd00:28::1", "172.28.0.1"},
+		map[string]string{"router": "172.28.1.5"})
+	if err != nil {
+		t.Logf("got error building IPAM pools: %s", err)
+		t.FailNow()
+	}
+
+	want := []network.IPAMConfig{
+		{Subnet: "172.28.0.0/16", IPRange: "172.28.5.0/24", Gateway: "172.28.0.1",
+			AuxAddress: map[string]string{"router": "172.28.1.5"}},
+		{Subnet: "fd00:28::/64", Gateway: "fd00:28::1"},
+	}
+	if !reflect.DeepEqual(pools, want) {
+		t.Errorf("got pools %+v, want %+v", pools, want)
+	}
+}
+
+// TestNewIPAMPoolsErrors
+func TestNewIPAMPoolsErrors(t *testing.T) {
+	tables := []struct {
+		subnets  []string
+		ipRanges []string
+		gateways []string
+		aux      map[string]string
+		want     string
+	}{
+		{[]string{"10.0.0.0/33"}, nil, nil, nil, "invalid subnet 10.0.0.0/33: invalid CIDR address: 10.0.0.0/33"},
+		{[]string{"10.0.0.0/8", "10.1.0.0/16"}, nil, nil, nil,
+			"subnet 10.1.0.0/16 overlaps subnet 10.0.0.0/8 of network being created"},
+		{[]string{"10.0.0.0/16"}, []string{"10.1.0.0/24"}, nil, nil, "IP range 10.1.0.0/24 is not in any subnet"},
+		{[]string{"10.0.0.0/16"}, nil, []string{"10.0.0.1", "10.0.0.2"}, nil,
+			"subnet 10.0.0.0/16 has more than one gateway"},
+		{[]string{"10.0.0.0/16"}, nil, []string{"gateway"}, nil, "invalid gateway gateway"},
+		{[]string{"10.0.0.0/16"}, nil, nil, map[string]string{"db": "10.2.0.1"},
+			"aux address db=10.2.0.1 is not in any subnet"},
+	}
+
+	for _, table := range tables {
+		_, err := newIPAMPools(table.subnets, table.ipRanges, table.gateways, table.aux)
+		if err == nil || err.Error() != table.want {
+			t.Errorf("got error %v, want %s", err, table.want)
+		}
+	}
+}
+
+// TestCheckSubnetOverlap
+func TestCheckSubnetOverlap(t *testing.T) {
+	existing := []types.NetworkResource{
+		{Name: "bridge", IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "172.17.0.0/16"}}}},
+		{Name: "vpn", IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.8.0.0/24"}}}},
+	}
+
+	err := checkSubnetOverlap([]network.IPAMConfig{{Subnet: "10.0.0.0/12"}}, existing)
+	if overlap, ok := err.(*SubnetOverlapError); !ok || overlap.Network != "vpn" {
+		t.Errorf("got error %v, want overlap with vpn", err)
+	}
+
+	if err := checkSubnetOverlap([]network.IPAMConfig{{Subnet: "192.168.50.0/24"}}, existing); err != nil {
+		t.Errorf("got error for free subnet: %s", err)
+	}
+}
@@ -0,0 +1,223 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// Dockerfile instructions that only change image metadata and never add a
+// filesystem layer.
+var metadataInstructions = []string{"ARG", "CMD", "ENTRYPOINT", "ENV", "EXPOSE",
+	"HEALTHCHECK", "LABEL", "MAINTAINER", "ONBUILD", "SHELL", "STOPSIGNAL",
+	"USER", "VOLUME"}
+
+// LayerUsage describes a single filesystem layer and the cached images
+// built on it.
+type LayerUsage struct {
+	// ChainID identifies the layer together with every layer below it,
+	// the same way the daemon stores layers on disk.
+	ChainID   string
+	DiffID    string
+	CreatedBy string
+	Size      int64
+	Images    []string
+}
+
+// ImageLayerUsage splits an image's size into bytes shared with other
+// cached images and bytes used by this image alone.
+type ImageLayerUsage struct {
+	ID         string
+	Name       string
+	Size       int64
+	SharedSize int64
+	UniqueSize int64
+}
+
+// LayerBreakdown is the layer usage of every cached image.
+type LayerBreakdown struct {
+	Images []ImageLayerUsage
+	// Layers is sorted by size, largest first.
+	Layers     []LayerUsage
+	SharedSize int64
+	UniqueSize int64
+}
+
+// imageLayers holds the information needed to size an image's layers.
+type imageLayers struct {
+	id      string
+	name    string
+	size    int64
+	diffIDs []string
+	history []image.HistoryResponseItem
+}
+
+// isEmptyLayer reports whether a history entry only changed metadata. The
+// Engine API omits this flag, so it is inferred from the entry's command.
+func isEmptyLayer(item image.HistoryResponseItem) bool {
+	if item.Size > 0 {
+		return false
+	}
+
+	createdBy := item.CreatedBy
+	if i := strings.Index(createdBy, "#(nop)"); i >= 0 {
+		createdBy = strings.TrimSpace(createdBy[i+len("#(nop)"):])
+		return !strings.HasPrefix(createdBy, "ADD") && !strings.HasPrefix(createdBy, "COPY")
+	}
+
+	for _, instruction := range metadataInstructions {
+		if strings.HasPrefix(createdBy, instruction+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// chainIDs returns the chain ID of every layer in diffIDs.
+func chainIDs(diffIDs []string) []string {
+	ids := make([]string, len(diffIDs))
+
+	for i, diffID := range diffIDs {
+		if i == 0 {
+			ids[i] = diffID
+			continue
+		}
+		ids[i] = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(ids[i-1]+" "+diffID)))
+	}
+	return ids
+}
+
+// layerHistory matches each of an image's layers, oldest first, to the
+// history entry that created it. It returns nil if the history cannot be
+// matched to the layers.
+func layerHistory(img imageLayers) []image.HistoryResponseItem {
+	var layers []image.HistoryResponseItem
+
+	for i := len(img.history) - 1; i >= 0; i-- {
+		if !isEmptyLayer(img.history[i]) {
+			layers = append(layers, img.history[i])
+		}
+	}
+
+	if len(layers) != len(img.diffIDs) {
+		return nil
+	}
+	return layers
+}
+
+// computeLayerBreakdown groups the layers of images by chain ID and
+// attributes their sizes to the images that use them.
+func computeLayerBreakdown(images []imageLayers) LayerBreakdown {
+	var breakdown LayerBreakdown
+	layers := make(map[string]*LayerUsage)
+	imageChains := make([][]string, len(images))
+
+	var unmatched []int
+	for i, img := range images {
+		history := layerHistory(img)
+		if history == nil {
+			unmatched = append(unmatched, i)
+			continue
+		}
+
+		imageChains[i] = chainIDs(img.diffIDs)
+		for j, chainID := range imageChains[i] {
+			layer, ok := layers[chainID]
+			if !ok {
+				layer = &LayerUsage{
+					ChainID:   chainID,
+					DiffID:    img.diffIDs[j],
+					CreatedBy: history[j].CreatedBy,
+					Size:      history[j].Size,
+				}
+				layers[chainID] = layer
+			}
+			layer.Images = append(layer.Images, img.id)
+		}
+	}
+
+	// An image whose history cannot be matched still uses the layers sized
+	// from other images; the rest of its size is counted as unique.
+	unsized := make([]int64, len(images))
+	for _, i := range unmatched {
+		unsized[i] = images[i].size
+
+		for _, chainID := range chainIDs(images[i].diffIDs) {
+			if layer, ok := layers[chainID]; ok {
+				layer.Images = append(layer.Images, images[i].id)
+				imageChains[i] = append(imageChains[i], chainID)
+				unsized[i] -= layer.Size
+			}
+		}
+		if unsized[i] < 0 {
+			unsized[i] = 0
+		}
+	}
+
+	for i, img := range images {
+		usage := ImageLayerUsage{ID: img.id, Name: img.name, Size: img.size}
+		usage.UniqueSize = unsized[i]
+		breakdown.UniqueSize += unsized[i]
+
+		for _, chainID := range imageChains[i] {
+			if layer := layers[chainID]; len(layer.Images) > 1 {
+				usage.SharedSize += layer.Size
+			} else {
+				usage.UniqueSize += layer.Size
+			}
+		}
+		breakdown.Images = append(breakdown.Images, usage)
+	}
+
+	for _, layer := range layers {
+		if len(layer.Images) > 1 {
+			breakdown.SharedSize += layer.Size
+		} else {
+			breakdown.UniqueSize += layer.Size
+		}
+		breakdown.Layers = append(breakdown.Layers, *layer)
+	}
+
+	sort.Slice(breakdown.Layers, func(i, j int) bool {
+		if breakdown.Layers[i].Size != breakdown.Layers[j].Size {
+			return breakdown.Layers[i].Size > breakdown.Layers[j].Size
+		}
+		return breakdown.Layers[i].ChainID < breakdown.Layers[j].ChainID
+	})
+	return breakdown
+}
+
+// LayerBreakdown inspects every cached image and reports which layer bytes
+// are shared between images and which are unique to one image. Shared
+// bytes are only stored once on disk, so SharedSize plus UniqueSize is the
+// space actually used by the cached images. Layers can only be sized from
+// an image whose history matches its layers, so a layer found solely in
+// images without such a match is counted as unique to each of them.
+func (di *DockerInterface) LayerBreakdown(ctx context.Context) (LayerBreakdown, error) {
+	images := make([]imageLayers, 0, len(di.Images))
+
+	for _, summary := range di.Images {
+		inspect, err := di.InspectImage(ctx, summary.ID)
+		if err != nil {
+			return LayerBreakdown{}, err
+		}
+
+		history, err := di.ImageHistory(ctx, summary.ID)
+		if err != nil {
+			return LayerBreakdown{}, err
+		}
+
+		images = append(images, imageLayers{
+			id:      summary.ID,
+			name:    imageName(summary),
+			size:    summary.Size,
+			diffIDs: inspect.RootFS.Layers,
+			history: history,
+		})
+	}
+	return computeLayerBreakdown(images), nil
+}
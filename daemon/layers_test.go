@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// Two images sharing a base layer, newest history entry first.
+var testImageLayers = []imageLayers{
+	{
+		id: "sha256:aaa", name: "app:1", size: 130,
+		diffIDs: []string{"sha256:base", "sha256:app1"},
+		history: []image.HistoryResponseItem{
+			{CreatedBy: "/bin/sh -c #(nop)  CMD [\"app\"]"},
+			{CreatedBy: "/bin/sh -c #(nop) COPY file:abc in /app", Size: 30},
+			{CreatedBy: "/bin/sh -c #(nop) ADD file:def in / ", Size: 100},
+		},
+	},
+	{
+		id: "sha256:bbb", name: "app:2", size: 150,
+		diffIDs: []string{"sha256:base", "sha256:app2"},
+		history: []image.HistoryResponseItem{
+			{CreatedBy: "ENV MODE=prod"},
+			{CreatedBy: "RUN /bin/sh -c make # buildkit", Size: 50},
+			{CreatedBy: "/bin/sh -c #(nop) ADD file:def in / ", Size: 100},
+		},
+	},
+	{
+		id: "sha256:ccc", name: "odd:1", size: 70,
+		diffIDs: []string{"sha256:other"},
+		history: []image.HistoryResponseItem{
+			{CreatedBy: "RUN true", Size: 0},
+			{CreatedBy: "RUN make", Size: 70},
+		},
+	},
+}
+
+// TestComputeLayerBreakdown
+func TestComputeLayerBreakdown(t *testing.T) {
+	breakdown := computeLayerBreakdown(testImageLayers)
+
+	want := []ImageLayerUsage{
+		{"sha256:aaa", "app:1", 130, 100, 30},
+		{"sha256:bbb", "app:2", 150, 100, 50},
+		{"sha256:ccc", "odd:1", 70, 0, 70},
+	}
+	for i, usage := range breakdown.Images {
+		if usage != want[i] {
+			t.Errorf("got usage %+v, want %+v", usage, want[i])
+		}
+	}
+
+	if breakdown.SharedSize != 100 || breakdown.UniqueSize != 150 {
+		t.Errorf("got %d shared and %d unique bytes, want 100 and 150",
+			breakdown.SharedSize, breakdown.UniqueSize)
+	}
+	if len(breakdown.Layers) != 3 || len(breakdown.Layers[0].Images) != 2 {
+		t.Errorf("got %d layers, want 3 with the shared base first", len(breakdown.Layers))
+	}
+}
+
+// TestComputeLayerBreakdownUnmatched
+func TestComputeLayerBreakdownUnmatched(t *testing.T) {
+	images := append([]imageLayers{}, testImageLayers...)
+	images = append(images, imageLayers{
+		id: "sha256:ddd", name: "squashed:1", size: 120,
+		diffIDs: []string{"sha256:base", "sha256:extra"},
+		history: []image.HistoryResponseItem{{CreatedBy: "RUN make", Size: 120}},
+	})
+
+	breakdown := computeLayerBreakdown(images)
+
+	want := ImageLayerUsage{"sha256:ddd", "squashed:1", 120, 100, 20}
+	if got := breakdown.Images[3]; got != want {
+		t.Errorf("got usage %+v, want %+v", got, want)
+	}
+	if breakdown.SharedSize != 100 || breakdown.UniqueSize != 170 {
+		t.Errorf("got %d shared and %d unique bytes, want 100 and 170",
+			breakdown.SharedSize, breakdown.UniqueSize)
+	}
+	if len(breakdown.Layers[0].Images) != 3 {
+		t.Errorf("got base layer images %v, want 3", breakdown.Layers[0].Images)
+	}
+}
+
+// TestLayerBreakdown
+func TestLayerBreakdown(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	di.PullImage(ctx, "nginx")
+
+	breakdown, err := di.LayerBreakdown(ctx)
+	if err != nil {
+		t.Logf("got error computing layer breakdown: %s", err)
+		t.FailNow()
+	}
+	if len(breakdown.Images) != di.NumImages() {
+		t.Errorf("got %d images in breakdown, want %d", len(breakdown.Images), di.NumImages())
+	}
+}
@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"fmt"
+	"net"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
@@ -15,8 +16,11 @@ type networkConfig struct {
 }
 
 // newNetworkConfig takes a map of options and creates the necessary
-// configuration struct to create a new network.
-func newNetworkConfig(opts map[string]string) *networkConfig {
+// configuration struct to create a new network. Labels, driver options,
+// IPAM options and aux addresses are key=value lists, and subnets, IP
+// ranges and gateways are comma-separated lists, all parsed as in
+// ParseKeyValues. An IPv6 subnet enables IPv6 on the network.
+func newNetworkConfig(opts map[string]string) (*networkConfig, error) {
 	config := &networkConfig{
 		Config: &types.NetworkCreate{},
 		Name:   opts["name"],
@@ -37,13 +41,67 @@ func newNetworkConfig(opts map[string]string) *networkConfig {
 	if opts["internal"] != "" {
 		config.Config.Internal = true
 	}
-	return config
+
+	var err error
+	if config.Config.Labels, err = ParseKeyValues("label", opts["labels"]); err != nil {
+		return nil, err
+	}
+	if config.Config.Options, err = ParseKeyValues("option", opts["options"]); err != nil {
+		return nil, err
+	}
+
+	ipam := &network.IPAM{Driver: opts["ipamDriver"]}
+	if ipam.Options, err = ParseKeyValues("IPAM option", opts["ipamOptions"]); err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string][]string)
+	for _, key := range []string{"subnet", "ipRange", "gateway"} {
+		if lists[key], err = splitOptionList(key, opts[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	auxAddresses, err := ParseKeyValues("aux address", opts["auxAddress"])
+	if err != nil {
+		return nil, err
+	}
+
+	ipam.Config, err = newIPAMPools(lists["subnet"], lists["ipRange"], lists["gateway"], auxAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range ipam.Config {
+		if ip, _, _ := net.ParseCIDR(pool.Subnet); ip.To4() == nil {
+			config.Config.EnableIPv6 = true
+		}
+	}
+
+	config.Config.IPAM = ipam
+	return config, nil
 }
 
 // NewNetwork creates a new network with the provided options and returns
-// the network's ID.
+// the network's ID. Requested subnets are checked against those of every
+// existing network first, and an overlap is reported as
+// *SubnetOverlapError.
 func (di *DockerInterface) NewNetwork(ctx context.Context, opts map[string]string) (string, error) {
-	config := newNetworkConfig(opts)
+	config, err := newNetworkConfig(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if len(config.Config.IPAM.Config) > 0 {
+		existing, err := di.Client.NetworkList(ctx, types.NetworkListOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to create network: %s", err)
+		}
+
+		if err := checkSubnetOverlap(config.Config.IPAM.Config, existing); err != nil {
+			return "", err
+		}
+	}
 
 	response, err := di.Client.NetworkCreate(
 		ctx,
@@ -53,7 +111,19 @@ func (di *DockerInterface) NewNetwork(ctx context.Context, opts map[string]strin
 	if err != nil {
 		return "", fmt.Errorf("failed to create network: %s", err)
 	}
-	return response.ID, di.RefreshNetworks(ctx)
+	return response.ID, di.RefreshNetwork(ctx, response.ID)
+}
+
+// InspectNetwork returns the JSON file generated by the Docker Engine for the
+// given network. In verbose mode, swarm-scoped networks also report their
+// services and peers across the cluster.
+func (di *DockerInterface) InspectNetwork(ctx context.Context,
+	id string, verbose bool) (types.NetworkResource, error) {
+	response, err := di.Client.NetworkInspect(ctx, id, types.NetworkInspectOptions{Verbose: verbose})
+	if err != nil {
+		return types.NetworkResource{}, fmt.Errorf("failed to fetch network: %s", err)
+	}
+	return response, nil
 }
 
 // RemoveNetwork removes a network.
@@ -61,7 +131,16 @@ func (di *DockerInterface) RemoveNetwork(ctx context.Context, id string) error {
 	if err := di.Client.NetworkRemove(ctx, id); err != nil {
 		return fmt.Errorf("failed to remove network: %s", err)
 	}
-	return di.RefreshNetworks(ctx)
+	return di.RefreshNetwork(ctx, id)
+}
+
+// refreshEndpoint updates the cached network and container at either end of
+// a network endpoint.
+func (di *DockerInterface) refreshEndpoint(ctx context.Context, net, container string) error {
+	if err := di.RefreshNetwork(ctx, net); err != nil {
+		return err
+	}
+	return di.RefreshContainer(ctx, container)
 }
 
 // ConnectNetwork connects a container to a network.
@@ -70,7 +149,7 @@ func (di *DockerInterface) ConnectNetwork(ctx context.Context, net, container st
 		ctx, net, container, &network.EndpointSettings{}); err != nil {
 		return fmt.Errorf("failed to connect network: %s", err)
 	}
-	return nil
+	return di.refreshEndpoint(ctx, net, container)
 }
 
 // DisconnectNetwork removes a container from a network.
@@ -78,7 +157,7 @@ func (di *DockerInterface) DisconnectNetwork(ctx context.Context, net, container
 	if err := di.Client.NetworkDisconnect(ctx, net, container, true); err != nil {
 		return fmt.Errorf("failed to disconnect network: %s", err)
 	}
-	return nil
+	return di.refreshEndpoint(ctx, net, container)
 }
 
 // NumNetworks returns the current number of networks.
@@ -79,6 +79,71 @@ func TestNewNetwork(t *testing.T) {
 	}
 }
 
+// TestNewNetworkConfig
+func TestNewNetworkConfig(t *testing.T) {
+	opts := map[string]string{
+		"name":        "vpn_safe",
+		"labels":      "team=payments",
+		"options":     "com.docker.network.bridge.name=br-vpn,com.docker.network.driver.mtu=1400",
+		"ipamDriver":  "default",
+		"subnet":      "192.168.90.0/24,fd00:90::/64",
+		"gateway":     "192.168.90.1",
+		"ipRange":     "192.168.90.128/25",
+		"auxAddress":  "router=192.168.90.2",
+		"ipamOptions": "",
+	}
+
+	config, err := newNetworkConfig(opts)
+	if err != nil {
+		t.Logf("got error building network config: %s", err)
+		t.FailNow()
+	}
+
+	if config.Config.Labels["team"] != "payments" ||
+		config.Config.Options["com.docker.network.driver.mtu"] != "1400" {
+		t.Errorf("got labels %v and options %v", config.Config.Labels, config.Config.Options)
+	}
+	if ipam := config.Config.IPAM; ipam.Driver != "default" || len(ipam.Config) != 2 ||
+		ipam.Config[0].Gateway != "192.168.90.1" || ipam.Config[0].IPRange != "192.168.90.128/25" {
+		t.Errorf("got IPAM %+v", ipam)
+	}
+	if !config.Config.EnableIPv6 {
+		t.Error("expected IPv6 subnet to enable IPv6")
+	}
+
+	for _, bad := range []map[string]string{
+		{"labels": "team"},
+		{"subnet": "192.168.90.0/24", "gateway": "10.0.0.1"},
+	} {
+		if _, err := newNetworkConfig(bad); err == nil {
+			t.Errorf("expected error building network config from %v", bad)
+		}
+	}
+}
+
+// TestNewNetworkSubnetOverlap
+func TestNewNetworkSubnetOverlap(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	id, err := di.NewNetwork(ctx, map[string]string{"name": "subnet1", "subnet": "10.213.0.0/16"})
+	if err != nil {
+		t.Logf("got error creating network: %s", err)
+		t.FailNow()
+	}
+	defer di.RemoveNetwork(ctx, id)
+
+	network, _ := getNetwork(id)
+	if len(network.IPAM.Config) != 1 || network.IPAM.Config[0].Subnet != "10.213.0.0/16" {
+		t.Errorf("got IPAM config %+v", network.IPAM.Config)
+	}
+
+	_, err = di.NewNetwork(ctx, map[string]string{"name": "subnet2", "subnet": "10.213.4.0/24"})
+	if overlap, ok := err.(*SubnetOverlapError); !ok || overlap.Network != "subnet1" {
+		t.Errorf("got error %v, want overlap with subnet1", err)
+	}
+}
+
 // TestRemoveNetwork
 func TestRemoveNetwork(t *testing.T) {
 	ctx := context.TODO()
@@ -109,9 +174,25 @@ func TestConnectNetwork(t *testing.T) {
 
 	conID, _ := di.NewContainer(ctx, testContainer)
 	defer di.RemoveContainer(ctx, conID)
+	di.StartContainer(ctx, conID)
 
 	if err := di.ConnectNetwork(ctx, netID, conID); err != nil {
-		t.Errorf("got error connecting network: %s", err)
+		t.Logf("got error connecting network: %s", err)
+		t.FailNow()
+	}
+
+	for _, container := range di.Containers {
+		if container.ID != conID {
+			continue
+		}
+		if _, ok := container.NetworkSettings.Networks["test_network"]; !ok {
+			t.Error("cached container does not list connected network")
+		}
+	}
+	for _, network := range di.Topology().Networks {
+		if network.ID == netID && len(network.Endpoints) != 1 {
+			t.Errorf("got endpoints %+v, want connected container", network.Endpoints)
+		}
 	}
 }
 
@@ -127,10 +208,18 @@ func TestDisconnectNetwork(t *testing.T) {
 	defer di.RemoveNetwork(ctx, netID)
 
 	conID, _ := di.NewContainer(ctx, testContainer)
-	di.ConnectNetwork(ctx, netID, conID)
 	defer di.RemoveContainer(ctx, conID)
+	di.StartContainer(ctx, conID)
+	di.ConnectNetwork(ctx, netID, conID)
 
 	if err := di.DisconnectNetwork(ctx, netID, conID); err != nil {
-		t.Errorf("got error disconnecting network: %s", err)
+		t.Logf("got error disconnecting network: %s", err)
+		t.FailNow()
+	}
+
+	for _, network := range di.Topology().Networks {
+		if network.ID == netID && len(network.Endpoints) != 0 {
+			t.Errorf("got endpoints %+v, want none after disconnect", network.Endpoints)
+		}
 	}
 }
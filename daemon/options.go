@@ -0,0 +1,174 @@
+package daemon
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// OptionError describes a malformed entry in a key=value option list.
+type OptionError struct {
+	Option string
+	Entry  string
+	Reason string
+}
+
+// Error is called when an option list cannot be parsed.
+func (o *OptionError) Error() string {
+	return fmt.Sprintf("invalid %s entry %q: %s", o.Option, o.Entry, o.Reason)
+}
+
+// splitOptionList splits a comma-separated list into its entries. Entries
+// that contain commas or line breaks may be enclosed in double quotes, with
+// literal quotes doubled, as in docker's --mount flag. A line break outside
+// quotes is reported as *OptionError.
+func splitOptionList(option string, list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(list))
+	reader.TrimLeadingSpace = true
+
+	entries, err := reader.Read()
+	if err != nil {
+		return nil, &OptionError{option, list, err.Error()}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		reason := "unexpected line break"
+		if err != nil {
+			reason = err.Error()
+		}
+		return nil, &OptionError{option, list, reason}
+	}
+	return entries, nil
+}
+
+// splitArgList splits a comma-separated list of command arguments or
+// environment variables, as given to NewContainer, trimming each unquoted
+// entry. An entry that starts with a double quote runs to the closing
+// quote, with literal quotes doubled, and may contain commas. Quotes inside
+// an unquoted entry and line breaks anywhere are kept as they are. An
+// unterminated quote or text after a closing quote is reported as
+// *OptionError.
+func splitArgList(option string, list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	var entries []string
+	rest := list
+
+	for {
+		var entry string
+
+		if trimmed := strings.TrimLeftFunc(rest, unicode.IsSpace); strings.HasPrefix(trimmed, `"`) {
+			end := 1
+			for {
+				i := strings.IndexByte(trimmed[end:], '"')
+				if i < 0 {
+					return nil, &OptionError{option, list, "unterminated quote"}
+				}
+				end += i + 1
+				if !strings.HasPrefix(trimmed[end:], `"`) {
+					break
+				}
+				end++
+			}
+
+			entry = strings.ReplaceAll(trimmed[1:end-1], `""`, `"`)
+			rest = strings.TrimLeftFunc(trimmed[end:], unicode.IsSpace)
+			if rest != "" && rest[0] != ',' {
+				return nil, &OptionError{option, list, "text after closing quote"}
+			}
+		} else {
+			i := strings.IndexByte(rest, ',')
+			if i < 0 {
+				i = len(rest)
+			}
+			entry, rest = strings.TrimSpace(rest[:i]), rest[i:]
+		}
+
+		entries = append(entries, entry)
+		if rest == "" {
+			return entries, nil
+		}
+		rest = rest[1:]
+	}
+}
+
+// formatOptionList is the inverse of splitOptionList and splitArgList. It
+// joins entries with commas, quoting entries where needed.
+func formatOptionList(entries []string) string {
+	quoted := make([]string, len(entries))
+
+	for i, entry := range entries {
+		quoted[i] = entry
+		if strings.ContainsAny(entry, ",\"\n") || entry != strings.TrimSpace(entry) {
+			quoted[i] = `"` + strings.ReplaceAll(entry, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(quoted, ",")
+}
+
+// KeyValues parses entries of the form key=value, as given to repeated
+// --label or --opt flags, into a map. The value is everything after the
+// first "=" and may itself contain "=" and commas; use "key=" for an empty
+// value. Missing keys, missing "=" and repeated keys are reported as
+// *OptionError.
+func KeyValues(option string, entries []string) (map[string]string, error) {
+	values := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		keyValue := strings.SplitN(entry, "=", 2)
+		key := strings.TrimSpace(keyValue[0])
+
+		switch {
+		case len(keyValue) < 2:
+			return nil, &OptionError{option, entry, `missing "="`}
+		case key == "":
+			return nil, &OptionError{option, entry, "missing key"}
+		}
+
+		if _, ok := values[key]; ok {
+			return nil, &OptionError{option, entry, "duplicate key " + key}
+		}
+		values[key] = keyValue[1]
+	}
+	return values, nil
+}
+
+// ParseKeyValues parses a comma-separated list of key=value entries into a
+// map. Entries whose value contains a comma must be quoted, for example
+// `type=nfs,"o=addr=10.0.0.1,rw",device=:/export`.
+func ParseKeyValues(option string, list string) (map[string]string, error) {
+	entries, err := splitOptionList(option, list)
+	if err != nil {
+		return nil, err
+	}
+	return KeyValues(option, entries)
+}
+
+// FormatKeyValues is the inverse of ParseKeyValues. It formats values as a
+// comma-separated list in key order, quoting entries where needed.
+func FormatKeyValues(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, key+"="+values[key])
+	}
+	return formatOptionList(entries)
+}
@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseKeyValues
+func TestParseKeyValues(t *testing.T) {
+	tables := []struct {
+		list string
+		want map[string]string
+	}{
+		{"", map[string]string{}},
+		{"a=1, b=2", map[string]string{"a": "1", "b": "2"}},
+		{"empty=,eq=x=y", map[string]string{"empty": "", "eq": "x=y"}},
+		{`type=nfs,"o=addr=10.0.0.1,rw",device=:/export`,
+			map[string]string{"type": "nfs", "o": "addr=10.0.0.1,rw", "device": ":/export"}},
+		{`"quote=say ""hi"""`, map[string]string{"quote": `say "hi"`}},
+		{"a=1,,b=2,", map[string]string{"a": "1", "b": "2"}},
+	}
+
+	for _, table := range tables {
+		got, err := ParseKeyValues("option", table.list)
+		if err != nil {
+			t.Errorf("got error parsing %q: %s", table.list, err)
+		} else if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("got %v parsing %q, want %v", got, table.list, table.want)
+		}
+	}
+}
+
+// TestParseKeyValuesErrors
+func TestParseKeyValuesErrors(t *testing.T) {
+	tables := []struct {
+		list string
+		want string
+	}{
+		{"a=1,novalue", `invalid label entry "novalue": missing "="`},
+		{"=1", `invalid label entry "=1": missing key`},
+		{"a=1,a=2", `invalid label entry "a=2": duplicate key a`},
+		{`a="1`, `invalid label entry "a=\"1": parse error on line 1, column 3: bare " in non-quoted-field`},
+		{"a=1\nb=2", `invalid label entry "a=1\nb=2": unexpected line break`},
+		{"a=1\nb=\"2", `invalid label entry "a=1\nb=\"2": parse error on line 2, column 3: bare " in non-quoted-field`},
+	}
+
+	for _, table := range tables {
+		_, err := ParseKeyValues("label", table.list)
+
+		if _, ok := err.(*OptionError); !ok || err.Error() != table.want {
+			t.Errorf("got error %v parsing %q, want %s", err, table.list, table.want)
+		}
+	}
+}
+
+// TestFormatKeyValues
+func TestFormatKeyValues(t *testing.T) {
+	values := map[string]string{"type": "nfs", "o": "addr=10.0.0.1,rw", "q": `"x"`}
+
+	list := FormatKeyValues(values)
+	if list != `"o=addr=10.0.0.1,rw","q=""x""",type=nfs` {
+		t.Errorf("got formatted list %s", list)
+	}
+
+	got, err := ParseKeyValues("option", list)
+	if err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v and error %v parsing formatted list, want %v", got, err, values)
+	}
+}
+
+// TestFormatOptionList
+func TestFormatOptionList(t *testing.T) {
+	args := []string{"sh", "-c", `echo "a,b"`, " padded ", "PATH=/bin:/usr/bin"}
+
+	list := formatOptionList(args)
+	if list != `sh,-c,"echo ""a,b"""," padded ",PATH=/bin:/usr/bin` {
+		t.Errorf("got formatted list %s", list)
+	}
+	if got, err := splitArgList("cmd", list); err != nil || !reflect.DeepEqual(got, args) {
+		t.Errorf("got %q and error %v splitting formatted list, want %q", got, err, args)
+	}
+}
+
+// TestSplitArgList
+func TestSplitArgList(t *testing.T) {
+	tables := []struct {
+		list string
+		want []string
+	}{
+		{"", nil},
+		{`echo, say "hi"`, []string{"echo", `say "hi"`}},
+		{"sh,-c,echo a\necho b", []string{"sh", "-c", "echo a\necho b"}},
+		{`a, "b,c" ,d`, []string{"a", "b,c", "d"}},
+		{"a,", []string{"a", ""}},
+	}
+
+	for _, table := range tables {
+		got, err := splitArgList("cmd", table.list)
+		if err != nil || !reflect.DeepEqual(got, table.want) {
+			t.Errorf("got %q and error %v splitting %q, want %q", got, err, table.list, table.want)
+		}
+	}
+
+	for _, list := range []string{`echo,"a,b`, `"a"b,c`} {
+		if _, err := splitArgList("cmd", list); err == nil {
+			t.Errorf("expected error splitting %q", list)
+		} else if _, ok := err.(*OptionError); !ok {
+			t.Errorf("got error %v splitting %q, want *OptionError", err, list)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// streamMessage is a single message from a daemon JSON stream, matching the
+// wire format of the Docker CLI's jsonmessage package.
+type streamMessage struct {
+	ID       string `json:"id,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Stream   string `json:"stream,omitempty"`
+	Progress *struct {
+		Current int64 `json:"current,omitempty"`
+		Total   int64 `json:"total,omitempty"`
+	} `json:"progressDetail,omitempty"`
+	Error *struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	} `json:"errorDetail,omitempty"`
+	ErrorMessage string           `json:"error,omitempty"`
+	Aux          *json.RawMessage `json:"aux,omitempty"`
+}
+
+// LayerProgress is the latest reported state of a single image layer
+// during a pull or push. Current and Total count the bytes transferred;
+// Extracted and ExtractTotal count the bytes unpacked once a pulled layer
+// has downloaded.
+type LayerProgress struct {
+	ID           string
+	Status       string
+	Current      int64
+	Total        int64
+	Extracted    int64
+	ExtractTotal int64
+}
+
+// Progress is reported to a ProgressFunc for every message in an image
+// pull or push stream.
+type Progress struct {
+	// Status is the status text of the most recent message.
+	Status string
+	// Layer is the layer updated by the most recent message, or nil if the
+	// message was not about a single layer.
+	Layer *LayerProgress
+	// Layers holds every layer seen so far in the order first reported.
+	Layers []LayerProgress
+	// Current and Total are the bytes transferred and expected across all
+	// layers whose size is known.
+	Current int64
+	Total   int64
+}
+
+// ProgressFunc receives progress updates from long running image operations.
+type ProgressFunc func(Progress)
+
+// Percent returns the aggregate completion of all layers from 0 to 100.
+func (p Progress) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Current) / float64(p.Total) * 100
+}
+
+// ImageStreamError is returned when the daemon reports an error inside an
+// image pull, push, or build stream.
+type ImageStreamError struct {
+	Op      string
+	Code    int
+	Message string
+}
+
+// Error is called when the daemon reports an error inside a stream.
+func (e *ImageStreamError) Error() string {
+	return fmt.Sprintf("failed to %s image: %s", e.Op, e.Message)
+}
+
+// Layer status reported while a pulled layer is unpacked.
+const layerExtractingStatus = "Extracting"
+
+// Layer statuses reported once a layer needs no further transfer.
+var layerDoneStatuses = map[string]bool{
+	"Already exists":       true,
+	"Download complete":    true,
+	"Pull complete":        true,
+	"Layer already exists": true,
+	"Pushed":               true,
+}
+
+// progressTracker accumulates per-layer state from a stream and reports
+// it to a ProgressFunc.
+type progressTracker struct {
+	fn     ProgressFunc
+	layers map[string]*LayerProgress
+	order  []string
+}
+
+// newProgressTracker returns a tracker reporting to fn, which may be nil.
+func newProgressTracker(fn ProgressFunc) *progressTracker {
+	return &progressTracker{fn: fn, layers: make(map[string]*LayerProgress)}
+}
+
+// update records msg and reports the new aggregate progress.
+func (pt *progressTracker) update(msg streamMessage) {
+	if pt.fn == nil || msg.Status == "" {
+		return
+	}
+
+	progress := Progress{Status: msg.Status}
+
+	if msg.ID != "" && (msg.Progress != nil || layerDoneStatuses[msg.Status]) {
+		layer, ok := pt.layers[msg.ID]
+		if !ok {
+			layer = &LayerProgress{ID: msg.ID}
+			pt.layers[msg.ID] = layer
+			pt.order = append(pt.order, msg.ID)
+		}
+
+		layer.Status = msg.Status
+		switch {
+		case msg.Progress == nil || msg.Progress.Total <= 0:
+		case msg.Status == layerExtractingStatus:
+			layer.Extracted, layer.ExtractTotal = msg.Progress.Current, msg.Progress.Total
+		default:
+			layer.Current, layer.Total = msg.Progress.Current, msg.Progress.Total
+		}
+		if layerDoneStatuses[msg.Status] {
+			layer.Current = layer.Total
+		}
+		if msg.Status == "Pull complete" {
+			layer.Extracted = layer.ExtractTotal
+		}
+
+		current := *layer
+		progress.Layer = &current
+	}
+
+	progress.Layers = make([]LayerProgress, 0, len(pt.order))
+	for _, id := range pt.order {
+		layer := pt.layers[id]
+
+		progress.Layers = append(progress.Layers, *layer)
+		progress.Current += layer.Current
+		progress.Total += layer.Total
+	}
+	pt.fn(progress)
+}
+
+// decodeStream reads a JSON message stream from the daemon, passing each
+// message to handle. The first error reported inside the stream is
+// returned as an ImageStreamError for op.
+func decodeStream(r io.Reader, op string, handle func(streamMessage)) error {
+	decoder := json.NewDecoder(r)
+
+	for {
+		var msg streamMessage
+
+		if err := decoder.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to %s image: %s", op, err)
+		}
+
+		if msg.Error != nil {
+			return &ImageStreamError{op, msg.Error.Code, msg.Error.Message}
+		} else if msg.ErrorMessage != "" {
+			return &ImageStreamError{op, 0, msg.ErrorMessage}
+		}
+
+		if handle != nil {
+			handle(msg)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+// A pull stream with two layers, one of which already exists.
+const testPullStream = `{"status":"Pulling from library/busybox","id":"latest"}
+{"status":"Already exists","id":"aaa"}
+{"status":"Downloading","progressDetail":{"current":50,"total":200},"id":"bbb"}
+{"status":"Downloading","progressDetail":{"current":150,"total":200},"id":"bbb"}
+{"status":"Download complete","id":"bbb"}
+{"status":"Extracting","progressDetail":{"current":32,"total":500},"id":"bbb"}
+{"status":"Pull complete","id":"bbb"}
+{"status":"Digest: sha256:0123"}
+`
+
+// TestDecodeStreamProgress
+func TestDecodeStreamProgress(t *testing.T) {
+	var updates []Progress
+
+	tracker := newProgressTracker(func(p Progress) { updates = append(updates, p) })
+	if err := decodeStream(strings.NewReader(testPullStream), "pull", tracker.update); err != nil {
+		t.Logf("got error decoding stream: %s", err)
+		t.FailNow()
+	}
+
+	if len(updates) != 8 {
+		t.Logf("got %d updates, want 8", len(updates))
+		t.FailNow()
+	}
+	if got := updates[3]; got.Layer == nil || got.Layer.Current != 150 || got.Percent() != 75 {
+		t.Errorf("got layer %v at %.0f%%, want bbb at 75%%", got.Layer, got.Percent())
+	}
+	if got := updates[5]; got.Current != 200 || got.Total != 200 ||
+		got.Layer == nil || got.Layer.Extracted != 32 || got.Layer.ExtractTotal != 500 {
+		t.Errorf("got %d/%d bytes and layer %v while extracting, want 200/200 and 32/500",
+			got.Current, got.Total, got.Layer)
+	}
+	if got := updates[6]; got.Current != 200 || got.Total != 200 {
+		t.Errorf("got %d/%d bytes after pull complete, want 200/200", got.Current, got.Total)
+	}
+	if got := updates[7]; got.Layer != nil || len(got.Layers) != 2 {
+		t.Errorf("got layer %v and %d layers, want nil and 2", got.Layer, len(got.Layers))
+	}
+}
+
+// TestDecodeStreamError
+func TestDecodeStreamError(t *testing.T) {
+	stream := testPullStream + `{"errorDetail":{"message":"no space left on device"},` +
+		`"error":"no space left on device"}` + "\n"
+
+	err := decodeStream(strings.NewReader(stream), "pull", nil)
+	if streamErr, ok := err.(*ImageStreamError); !ok {
+		t.Errorf("got error %v, want *ImageStreamError", err)
+	} else if streamErr.Message != "no space left on device" {
+		t.Errorf("got message %q, want no space left on device", streamErr.Message)
+	}
+}
@@ -0,0 +1,377 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Networks created by the daemon, which are never pruned.
+var predefinedNetworks = map[string]bool{"bridge": true, "host": true, "none": true}
+
+// PruneOptions selects which unused resources are pruned.
+type PruneOptions struct {
+	// Labels only prunes resources with every label, given as a key or a
+	// key=value pair.
+	Labels []string
+	// ExcludeLabels keeps resources that have every one of these labels,
+	// as the daemon's label! filter does.
+	ExcludeLabels []string
+	// Until only prunes resources created before this time. Volumes do not
+	// support it, so PruneVolumes, and SystemPrune with Volumes set, return
+	// an error rather than prune volumes of any age.
+	Until time.Time
+	// All prunes every unused image rather than only dangling images.
+	All bool
+	// Volumes includes volumes in SystemPrune.
+	Volumes bool
+	// DryRun reports what would be pruned, using the cached resource lists,
+	// without removing anything.
+	DryRun bool
+}
+
+// checkVolumeUntil returns an error if opts sets Until, which volume prunes
+// cannot honour.
+func checkVolumeUntil(opts PruneOptions) error {
+	if !opts.Until.IsZero() {
+		return fmt.Errorf("failed to prune volumes: the until filter is not supported for volumes")
+	}
+	return nil
+}
+
+// PruneReport lists the resources removed by a prune and the disk space
+// reclaimed. For a dry run it lists what would be removed.
+type PruneReport struct {
+	DryRun            bool
+	ContainersDeleted []string
+	ImagesDeleted     []string
+	VolumesDeleted    []string
+	NetworksDeleted   []string
+	SpaceReclaimed    uint64
+}
+
+// resourceSizes holds the disk usage of individual resources, used to
+// estimate the space reclaimed by a dry run.
+type resourceSizes struct {
+	containers map[string]int64
+	images     map[string]int64
+	volumes    map[string]int64
+}
+
+// merge adds the resources and space of other to the report.
+func (pr *PruneReport) merge(other PruneReport) {
+	pr.ContainersDeleted = append(pr.ContainersDeleted, other.ContainersDeleted...)
+	pr.ImagesDeleted = append(pr.ImagesDeleted, other.ImagesDeleted...)
+	pr.VolumesDeleted = append(pr.VolumesDeleted, other.VolumesDeleted...)
+	pr.NetworksDeleted = append(pr.NetworksDeleted, other.NetworksDeleted...)
+	pr.SpaceReclaimed += other.SpaceReclaimed
+}
+
+// filters returns the daemon-side prune filters. until is omitted for
+// resources that do not support it.
+func (opts PruneOptions) filters(until bool) filters.Args {
+	args := filters.NewArgs()
+
+	addFilters(args, "label", opts.Labels)
+	addFilters(args, "label!", opts.ExcludeLabels)
+	if until && !opts.Until.IsZero() {
+		args.Add("until", strconv.FormatInt(opts.Until.Unix(), 10))
+	}
+	return args
+}
+
+// matches reports whether a resource with labels, created at created,
+// passes the label and until filters. A zero created time skips the
+// until filter.
+func (opts PruneOptions) matches(labels map[string]string, created time.Time) bool {
+	args := opts.filters(false)
+
+	if !args.MatchKVList("label", labels) {
+		return false
+	}
+	if len(opts.ExcludeLabels) > 0 && args.MatchKVList("label!", labels) {
+		return false
+	}
+	return opts.Until.IsZero() || created.IsZero() || created.Before(opts.Until)
+}
+
+// pruneSizes fetches the disk usage of every resource from the daemon.
+func (di *DockerInterface) pruneSizes(ctx context.Context) (resourceSizes, error) {
+	sizes := resourceSizes{
+		containers: make(map[string]int64),
+		images:     make(map[string]int64),
+		volumes:    make(map[string]int64),
+	}
+
+	usage, err := di.DiskUsage(ctx)
+	if err != nil {
+		return sizes, err
+	}
+
+	for _, container := range usage.ContainerItems {
+		sizes.containers[container.ID] = container.SizeRw
+	}
+	for _, image := range usage.ImageItems {
+		sizes.images[image.ID] = image.UniqueSize
+	}
+	for _, volume := range usage.VolumeItems {
+		if volume.Size > 0 {
+			sizes.volumes[volume.Name] = volume.Size
+		}
+	}
+	return sizes, nil
+}
+
+// planContainerPrune returns the cached containers a container prune would
+// remove.
+func (di *DockerInterface) planContainerPrune(opts PruneOptions) []types.Container {
+	return FilterContainers(di.Containers, func(container types.Container) bool {
+		switch container.State {
+		case "running", "paused", "restarting":
+			return false
+		}
+		return opts.matches(container.Labels, time.Unix(container.Created, 0))
+	})
+}
+
+// planImagePrune returns the cached images an image prune would remove,
+// treating the containers in removed as already gone.
+func (di *DockerInterface) planImagePrune(opts PruneOptions,
+	removed map[string]bool) []types.ImageSummary {
+	used := make(map[string]bool)
+	for _, container := range di.Containers {
+		if !removed[container.ID] {
+			used[container.ImageID] = true
+		}
+	}
+
+	parents := make(map[string]bool)
+	for _, image := range di.Images {
+		parents[image.ParentID] = true
+	}
+
+	return FilterImages(di.Images, func(image types.ImageSummary) bool {
+		if used[image.ID] || parents[image.ID] {
+			return false
+		}
+		if !opts.All && imageName(image) != "" {
+			return false
+		}
+		return opts.matches(image.Labels, time.Unix(image.Created, 0))
+	})
+}
+
+// planVolumePrune returns the cached volumes a volume prune would remove,
+// treating the containers in removed as already gone.
+func (di *DockerInterface) planVolumePrune(opts PruneOptions,
+	removed map[string]bool) []*types.Volume {
+	used := make(map[string]bool)
+	for _, container := range di.Containers {
+		if removed[container.ID] {
+			continue
+		}
+		for _, mount := range container.Mounts {
+			used[mount.Name] = true
+		}
+	}
+
+	return FilterVolumes(di.Volumes, func(volume *types.Volume) bool {
+		return volume.Scope == "local" && !used[volume.Name] &&
+			opts.matches(volume.Labels, time.Time{})
+	})
+}
+
+// planNetworkPrune returns the cached networks a network prune would
+// remove, treating the containers in removed as already gone.
+func (di *DockerInterface) planNetworkPrune(opts PruneOptions,
+	removed map[string]bool) []types.NetworkResource {
+	used := make(map[string]bool)
+	for _, container := range di.Containers {
+		if removed[container.ID] || container.NetworkSettings == nil {
+			continue
+		}
+		for name, endpoint := range container.NetworkSettings.Networks {
+			used[name] = true
+			used[endpoint.NetworkID] = true
+		}
+	}
+
+	return FilterNetworks(di.Networks, func(network types.NetworkResource) bool {
+		if predefinedNetworks[network.Name] || network.Ingress {
+			return false
+		}
+		if used[network.ID] || used[network.Name] || len(network.Containers) > 0 {
+			return false
+		}
+		return opts.matches(network.Labels, network.Created)
+	})
+}
+
+// dryRunPrune builds the report for a dry run of the selected prunes.
+func (di *DockerInterface) dryRunPrune(ctx context.Context, opts PruneOptions,
+	containers, images, volumes, networks bool) (PruneReport, error) {
+	report := PruneReport{DryRun: true}
+	removed := make(map[string]bool)
+
+	sizes, err := di.pruneSizes(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	if containers {
+		for _, container := range di.planContainerPrune(opts) {
+			removed[container.ID] = true
+			report.ContainersDeleted = append(report.ContainersDeleted, container.ID)
+			report.SpaceReclaimed += uint64(sizes.containers[container.ID])
+		}
+	}
+
+	if images {
+		for _, image := range di.planImagePrune(opts, removed) {
+			report.ImagesDeleted = append(report.ImagesDeleted, image.ID)
+			report.SpaceReclaimed += uint64(sizes.images[image.ID])
+		}
+	}
+
+	if volumes {
+		for _, volume := range di.planVolumePrune(opts, removed) {
+			report.VolumesDeleted = append(report.VolumesDeleted, volume.Name)
+			report.SpaceReclaimed += uint64(sizes.volumes[volume.Name])
+		}
+	}
+
+	if networks {
+		for _, network := range di.planNetworkPrune(opts, removed) {
+			report.NetworksDeleted = append(report.NetworksDeleted, network.ID)
+		}
+	}
+	return report, nil
+}
+
+// PruneContainers removes every stopped container matching opts.
+func (di *DockerInterface) PruneContainers(ctx context.Context,
+	opts PruneOptions) (PruneReport, error) {
+	if opts.DryRun {
+		return di.dryRunPrune(ctx, opts, true, false, false, false)
+	}
+
+	response, err := di.Client.ContainersPrune(ctx, opts.filters(true))
+	if err != nil {
+		return PruneReport{}, fmt.Errorf("failed to prune containers: %s", err)
+	}
+
+	report := PruneReport{
+		ContainersDeleted: response.ContainersDeleted,
+		SpaceReclaimed:    response.SpaceReclaimed,
+	}
+	return report, di.RefreshContainers(ctx)
+}
+
+// PruneImages removes every dangling image matching opts, or every image
+// not used by a container if opts.All is set.
+func (di *DockerInterface) PruneImages(ctx context.Context,
+	opts PruneOptions) (PruneReport, error) {
+	if opts.DryRun {
+		return di.dryRunPrune(ctx, opts, false, true, false, false)
+	}
+
+	args := opts.filters(true)
+	args.Add("dangling", strconv.FormatBool(!opts.All))
+
+	response, err := di.Client.ImagesPrune(ctx, args)
+	if err != nil {
+		return PruneReport{}, fmt.Errorf("failed to prune images: %s", err)
+	}
+
+	report := PruneReport{SpaceReclaimed: response.SpaceReclaimed}
+	for _, item := range response.ImagesDeleted {
+		if item.Deleted != "" {
+			report.ImagesDeleted = append(report.ImagesDeleted, item.Deleted)
+		}
+	}
+	return report, di.RefreshImages(ctx)
+}
+
+// PruneVolumes removes every local volume matching opts that is not used
+// by a container.
+func (di *DockerInterface) PruneVolumes(ctx context.Context,
+	opts PruneOptions) (PruneReport, error) {
+	if err := checkVolumeUntil(opts); err != nil {
+		return PruneReport{}, err
+	}
+
+	if opts.DryRun {
+		return di.dryRunPrune(ctx, opts, false, false, true, false)
+	}
+
+	response, err := di.Client.VolumesPrune(ctx, opts.filters(false))
+	if err != nil {
+		return PruneReport{}, fmt.Errorf("failed to prune volumes: %s", err)
+	}
+
+	report := PruneReport{
+		VolumesDeleted: response.VolumesDeleted,
+		SpaceReclaimed: response.SpaceReclaimed,
+	}
+	return report, di.RefreshVolumes(ctx)
+}
+
+// PruneNetworks removes every custom network matching opts that has no
+// connected containers.
+func (di *DockerInterface) PruneNetworks(ctx context.Context,
+	opts PruneOptions) (PruneReport, error) {
+	if opts.DryRun {
+		return di.dryRunPrune(ctx, opts, false, false, false, true)
+	}
+
+	response, err := di.Client.NetworksPrune(ctx, opts.filters(true))
+	if err != nil {
+		return PruneReport{}, fmt.Errorf("failed to prune networks: %s", err)
+	}
+
+	report := PruneReport{NetworksDeleted: response.NetworksDeleted}
+	return report, di.RefreshNetworks(ctx)
+}
+
+// SystemPrune prunes containers, then networks, then volumes if
+// opts.Volumes is set, then images, in the same order as docker system
+// prune. Images, networks, and volumes used only by pruned containers are
+// pruned too. Unlike docker system prune, it does not prune the build
+// cache.
+func (di *DockerInterface) SystemPrune(ctx context.Context,
+	opts PruneOptions) (PruneReport, error) {
+	if opts.Volumes {
+		if err := checkVolumeUntil(opts); err != nil {
+			return PruneReport{}, err
+		}
+	}
+
+	if opts.DryRun {
+		return di.dryRunPrune(ctx, opts, true, true, opts.Volumes, true)
+	}
+
+	report, err := di.PruneContainers(ctx, opts)
+	if err != nil {
+		return report, err
+	}
+
+	prunes := []func(context.Context, PruneOptions) (PruneReport, error){di.PruneNetworks}
+	if opts.Volumes {
+		prunes = append(prunes, di.PruneVolumes)
+	}
+	prunes = append(prunes, di.PruneImages)
+
+	for _, prune := range prunes {
+		pruned, err := prune(ctx, opts)
+		report.merge(pruned)
+
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
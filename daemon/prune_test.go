@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+)
+
+// Build an interface with cached resources in a mix of states.
+func newPruneInterface() *DockerInterface {
+	old := time.Now().Add(-48 * time.Hour)
+
+	return &DockerInterface{
+		Containers: []types.Container{
+			{ID: "running", State: "running", ImageID: "sha256:web",
+				Mounts: []types.MountPoint{{Name: "webdata"}},
+				NetworkSettings: &types.SummaryNetworkSettings{
+					Networks: map[string]*network.EndpointSettings{"frontend": {}}}},
+			{ID: "exited", State: "exited", ImageID: "sha256:job", Created: old.Unix(),
+				Labels: map[string]string{"team": "payments"},
+				Mounts: []types.MountPoint{{Name: "jobdata"}},
+				NetworkSettings: &types.SummaryNetworkSettings{
+					Networks: map[string]*network.EndpointSettings{"batch": {}}}},
+			{ID: "created", State: "created", ImageID: "sha256:job"},
+		},
+		Images: []types.ImageSummary{
+			{ID: "sha256:web", RepoTags: []string{"web:latest"}},
+			{ID: "sha256:job", RepoTags: []string{"job:latest"}},
+			{ID: "sha256:dangling", RepoTags: []string{"<none>:<none>"}},
+			{ID: "sha256:parent"},
+			{ID: "sha256:child", ParentID: "sha256:parent", RepoTags: []string{"child:1"}},
+		},
+		Volumes: []*types.Volume{
+			{Name: "webdata", Scope: "local"},
+			{Name: "jobdata", Scope: "local"},
+			{Name: "orphan", Scope: "local"},
+		},
+		Networks: []types.NetworkResource{
+			{ID: "n1", Name: "bridge"},
+			{ID: "n2", Name: "frontend"},
+			{ID: "n3", Name: "batch"},
+			{ID: "n4", Name: "unused"},
+		},
+	}
+}
+
+// TestPlanPrune
+func TestPlanPrune(t *testing.T) {
+	di := newPruneInterface()
+
+	containers := di.planContainerPrune(PruneOptions{})
+	if len(containers) != 2 {
+		t.Errorf("got %d containers to prune, want 2", len(containers))
+	}
+
+	containers = di.planContainerPrune(PruneOptions{
+		Labels: []string{"team=payments"}, Until: time.Now().Add(-24 * time.Hour)})
+	if len(containers) != 1 || containers[0].ID != "exited" {
+		t.Errorf("got %d containers to prune with filters, want only exited", len(containers))
+	}
+
+	removed := map[string]bool{"exited": true, "created": true}
+	tables := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"dangling images", len(di.planImagePrune(PruneOptions{}, nil)), 1},
+		{"unused images", len(di.planImagePrune(PruneOptions{All: true}, nil)), 2},
+		{"unused images after containers", len(di.planImagePrune(PruneOptions{All: true}, removed)), 3},
+		{"volumes", len(di.planVolumePrune(PruneOptions{}, nil)), 1},
+		{"volumes after containers", len(di.planVolumePrune(PruneOptions{}, removed)), 2},
+		{"networks", len(di.planNetworkPrune(PruneOptions{}, nil)), 1},
+		{"networks after containers", len(di.planNetworkPrune(PruneOptions{}, removed)), 2},
+	}
+
+	for _, table := range tables {
+		if table.got != table.want {
+			t.Errorf("got %d %s to prune, want %d", table.got, table.name, table.want)
+		}
+	}
+}
+
+// TestPruneVolumesUntil
+func TestPruneVolumesUntil(t *testing.T) {
+	ctx := context.TODO()
+	di := newPruneInterface()
+	opts := PruneOptions{Until: time.Now(), DryRun: true}
+
+	if _, err := di.PruneVolumes(ctx, opts); err == nil {
+		t.Error("expected error pruning volumes with until")
+	}
+
+	opts.Volumes = true
+	if _, err := di.SystemPrune(ctx, opts); err == nil {
+		t.Error("expected error pruning system with volumes and until")
+	}
+}
+
+// TestPruneContainers
+func TestPruneContainers(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	report, err := di.PruneContainers(ctx, PruneOptions{DryRun: true})
+	if err != nil {
+		t.Logf("got error previewing prune: %s", err)
+		t.FailNow()
+	}
+	if !containsString(report.ContainersDeleted, conID) {
+		t.Error("expected created container in prune preview")
+	}
+	if _, err := di.InspectContainer(ctx, conID); err != nil {
+		t.Error("dry run removed container")
+	}
+
+	report, err = di.PruneContainers(ctx, PruneOptions{})
+	if err != nil {
+		t.Logf("got error pruning containers: %s", err)
+		t.FailNow()
+	}
+	if !containsString(report.ContainersDeleted, conID) {
+		t.Error("expected created container to be pruned")
+	}
+}
+
+// TestPruneVolumes
+func TestPruneVolumes(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	name, _ := di.NewVolume(ctx, map[string]string{"name": "prune_volume",
+		"labels": "dockland.test=prune"})
+	defer di.RemoveVolume(ctx, name)
+
+	opts := PruneOptions{Labels: []string{"dockland.test=prune"}, DryRun: true}
+	report, err := di.PruneVolumes(ctx, opts)
+	if err != nil {
+		t.Logf("got error previewing prune: %s", err)
+		t.FailNow()
+	}
+	if len(report.VolumesDeleted) != 1 || report.VolumesDeleted[0] != name {
+		t.Errorf("got volumes %v in prune preview, want only %s", report.VolumesDeleted, name)
+	}
+
+	opts.DryRun = false
+	if report, err = di.PruneVolumes(ctx, opts); err != nil {
+		t.Logf("got error pruning volumes: %s", err)
+		t.FailNow()
+	}
+	if len(report.VolumesDeleted) != 1 {
+		t.Errorf("got %d volumes pruned, want 1", len(report.VolumesDeleted))
+	}
+}
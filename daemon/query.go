@@ -0,0 +1,224 @@
+package daemon
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ContainerQuery selects containers. The filter fields are evaluated by the
+// daemon; Match, if set, is then applied to each returned container.
+type ContainerQuery struct {
+	Labels   []string
+	Names    []string
+	Status   []string
+	Ancestor []string
+	Network  []string
+	Match    func(types.Container) bool
+}
+
+// ImageQuery selects images. The filter fields are evaluated by the daemon;
+// Match, if set, is then applied to each returned image.
+type ImageQuery struct {
+	Labels    []string
+	Reference []string
+	Dangling  *bool
+	Match     func(types.ImageSummary) bool
+}
+
+// NetworkQuery selects networks. The filter fields are evaluated by the
+// daemon; Match, if set, is then applied to each returned network.
+type NetworkQuery struct {
+	Labels []string
+	Names  []string
+	Driver []string
+	Match  func(types.NetworkResource) bool
+}
+
+// VolumeQuery selects volumes. The filter fields are evaluated by the
+// daemon; Match, if set, is then applied to each returned volume.
+type VolumeQuery struct {
+	Labels   []string
+	Names    []string
+	Driver   []string
+	Dangling *bool
+	Match    func(*types.Volume) bool
+}
+
+// addFilters adds each value to args under key.
+func addFilters(args filters.Args, key string, values []string) {
+	for _, value := range values {
+		args.Add(key, value)
+	}
+}
+
+// addBoolFilter adds value to args under key if it is set.
+func addBoolFilter(args filters.Args, key string, value *bool) {
+	if value != nil {
+		args.Add(key, strconv.FormatBool(*value))
+	}
+}
+
+// filters returns the daemon-side filters for the query.
+func (q ContainerQuery) filters() filters.Args {
+	args := filters.NewArgs()
+
+	addFilters(args, "label", q.Labels)
+	addFilters(args, "name", q.Names)
+	addFilters(args, "status", q.Status)
+	addFilters(args, "ancestor", q.Ancestor)
+	addFilters(args, "network", q.Network)
+	return args
+}
+
+// filters returns the daemon-side filters for the query.
+func (q ImageQuery) filters() filters.Args {
+	args := filters.NewArgs()
+
+	addFilters(args, "label", q.Labels)
+	addFilters(args, "reference", q.Reference)
+	addBoolFilter(args, "dangling", q.Dangling)
+	return args
+}
+
+// filters returns the daemon-side filters for the query.
+func (q NetworkQuery) filters() filters.Args {
+	args := filters.NewArgs()
+
+	addFilters(args, "label", q.Labels)
+	addFilters(args, "name", q.Names)
+	addFilters(args, "driver", q.Driver)
+	return args
+}
+
+// filters returns the daemon-side filters for the query.
+func (q VolumeQuery) filters() filters.Args {
+	args := filters.NewArgs()
+
+	addFilters(args, "label", q.Labels)
+	addFilters(args, "name", q.Names)
+	addFilters(args, "driver", q.Driver)
+	addBoolFilter(args, "dangling", q.Dangling)
+	return args
+}
+
+// QueryContainers returns the containers matching q. The cached Containers
+// field is left unchanged.
+func (di *DockerInterface) QueryContainers(ctx context.Context,
+	q ContainerQuery) ([]types.Container, error) {
+	containers, err := di.Client.ContainerList(ctx, types.ContainerListOptions{
+		All: true, Filters: di.scopeFilters(q.filters())})
+	if err != nil {
+		return nil, &ResourceRefreshError{"container list", err}
+	}
+
+	if q.Match == nil {
+		return containers, nil
+	}
+	return FilterContainers(containers, q.Match), nil
+}
+
+// QueryImages returns the images matching q. The cached Images field is
+// left unchanged.
+func (di *DockerInterface) QueryImages(ctx context.Context,
+	q ImageQuery) ([]types.ImageSummary, error) {
+	images, err := di.Client.ImageList(ctx, types.ImageListOptions{
+		All: true, Filters: di.scopeFilters(q.filters())})
+	if err != nil {
+		return nil, &ResourceRefreshError{"image list", err}
+	}
+
+	if q.Match == nil {
+		return images, nil
+	}
+	return FilterImages(images, q.Match), nil
+}
+
+// QueryNetworks returns the networks matching q. The cached Networks field
+// is left unchanged.
+func (di *DockerInterface) QueryNetworks(ctx context.Context,
+	q NetworkQuery) ([]types.NetworkResource, error) {
+	networks, err := di.Client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: di.scopeFilters(q.filters())})
+	if err != nil {
+		return nil, &ResourceRefreshError{"network list", err}
+	}
+
+	if q.Match == nil {
+		return networks, nil
+	}
+	return FilterNetworks(networks, q.Match), nil
+}
+
+// QueryVolumes returns the volumes matching q. The cached Volumes field is
+// left unchanged.
+func (di *DockerInterface) QueryVolumes(ctx context.Context,
+	q VolumeQuery) ([]*types.Volume, error) {
+	body, err := di.Client.VolumeList(ctx, di.scopeFilters(q.filters()))
+	if err != nil {
+		return nil, &ResourceRefreshError{"volume list", err}
+	}
+
+	if q.Match == nil {
+		return body.Volumes, nil
+	}
+	return FilterVolumes(body.Volumes, q.Match), nil
+}
+
+// FilterContainers returns the containers for which match returns true.
+// It is typically used on the cached Containers field.
+func FilterContainers(containers []types.Container,
+	match func(types.Container) bool) []types.Container {
+	var matched []types.Container
+
+	for _, container := range containers {
+		if match(container) {
+			matched = append(matched, container)
+		}
+	}
+	return matched
+}
+
+// FilterImages returns the images for which match returns true. It is
+// typically used on the cached Images field.
+func FilterImages(images []types.ImageSummary,
+	match func(types.ImageSummary) bool) []types.ImageSummary {
+	var matched []types.ImageSummary
+
+	for _, image := range images {
+		if match(image) {
+			matched = append(matched, image)
+		}
+	}
+	return matched
+}
+
+// FilterNetworks returns the networks for which match returns true. It is
+// typically used on the cached Networks field.
+func FilterNetworks(networks []types.NetworkResource,
+	match func(types.NetworkResource) bool) []types.NetworkResource {
+	var matched []types.NetworkResource
+
+	for _, network := range networks {
+		if match(network) {
+			matched = append(matched, network)
+		}
+	}
+	return matched
+}
+
+// FilterVolumes returns the volumes for which match returns true. It is
+// typically used on the cached Volumes field.
+func FilterVolumes(volumes []*types.Volume,
+	match func(*types.Volume) bool) []*types.Volume {
+	var matched []*types.Volume
+
+	for _, volume := range volumes {
+		if match(volume) {
+			matched = append(matched, volume)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// TestQueryFilters
+func TestQueryFilters(t *testing.T) {
+	dangling := true
+	tables := []struct {
+		got  []string
+		want []string
+	}{
+		{ContainerQuery{Status: []string{"running"}}.filters().Get("status"),
+			[]string{"running"}},
+		{ImageQuery{Dangling: &dangling}.filters().Get("dangling"),
+			[]string{"true"}},
+		{NetworkQuery{Driver: []string{"bridge"}}.filters().Get("driver"),
+			[]string{"bridge"}},
+		{VolumeQuery{Labels: []string{"case=1"}}.filters().Get("label"),
+			[]string{"case=1"}},
+		{VolumeQuery{}.filters().Get("dangling"), []string{}},
+	}
+
+	for _, table := range tables {
+		if !reflect.DeepEqual(table.got, table.want) {
+			t.Errorf("got filter values %v, want %v", table.got, table.want)
+		}
+	}
+}
+
+// TestQueryVolumes
+func TestQueryVolumes(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	name, _ := di.NewVolume(ctx, map[string]string{"name": "query_volume",
+		"labels": "com.example.team=payments"})
+	defer di.RemoveVolume(ctx, name)
+
+	volumes, err := di.QueryVolumes(ctx, VolumeQuery{
+		Labels: []string{"com.example.team=payments"},
+		Match:  func(v *types.Volume) bool { return v.Driver == "local" },
+	})
+	if err != nil {
+		t.Logf("got error querying volumes: %s", err)
+		t.FailNow()
+	}
+	if len(volumes) != 1 || volumes[0].Name != name {
+		t.Errorf("got %d volumes, want only %s", len(volumes), name)
+	}
+}
+
+// TestQueryContainers
+func TestQueryContainers(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	containers, err := di.QueryContainers(ctx, ContainerQuery{
+		Names: []string{"test_container"}, Status: []string{"created"}})
+	if err != nil {
+		t.Logf("got error querying containers: %s", err)
+		t.FailNow()
+	}
+	if len(containers) != 1 || containers[0].ID != conID {
+		t.Errorf("got %d containers, want only %s", len(containers), conID)
+	}
+
+	running := FilterContainers(di.Containers, func(c types.Container) bool {
+		return c.ID == conID && c.State == "running"
+	})
+	if len(running) != 0 {
+		t.Errorf("got %d running containers, want 0", len(running))
+	}
+}
+
+// TestScoped
+func TestScoped(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	name, _ := di.NewVolume(ctx, map[string]string{"name": "scoped_volume",
+		"labels": "com.example.team=payments"})
+	defer di.RemoveVolume(ctx, name)
+
+	scoped, err := di.Scoped(ctx, "com.example.team=payments")
+	if err != nil {
+		t.Logf("got error scoping interface: %s", err)
+		t.FailNow()
+	}
+	if scoped.NumVolumes() != 1 {
+		t.Errorf("got %d scoped volumes, want 1", scoped.NumVolumes())
+	}
+}
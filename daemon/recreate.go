@@ -0,0 +1,343 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+// Suffix added to a container's name while it is being replaced.
+const recreateSuffix = "_dockland_old"
+
+// RecreateOptions controls how a container is recreated.
+type RecreateOptions struct {
+	// Image overrides the image of the new container. An empty Image reuses
+	// the old container's image reference.
+	Image string
+	// NoPull skips pulling the image before creating the new container.
+	NoPull bool
+	// Timeout is the grace period for stopping the old container. nil
+	// uses the container's own stop timeout.
+	Timeout *time.Duration
+	// Pull is passed to PullImageWithOptions.
+	Pull PullOptions
+}
+
+// endpointConfig returns the user supplied settings of an endpoint without
+// the operational data assigned by the daemon.
+func endpointConfig(endpoint *network.EndpointSettings, oldID string) *network.EndpointSettings {
+	config := &network.EndpointSettings{
+		IPAMConfig: endpoint.IPAMConfig,
+		Links:      endpoint.Links,
+		DriverOpts: endpoint.DriverOpts,
+	}
+
+	// The daemon adds the container's short ID as an alias; the new
+	// container gets its own.
+	for _, alias := range endpoint.Aliases {
+		if !strings.HasPrefix(oldID, alias) {
+			config.Aliases = append(config.Aliases, alias)
+		}
+	}
+	return config
+}
+
+// equalStrings reports whether a and b hold the same strings in order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutImageDefaults returns a copy of config without the values it
+// inherited from image, the config of the image it was created from, so
+// that the new image's defaults take their place.
+func withoutImageDefaults(config container.Config, image *container.Config) container.Config {
+	if image == nil {
+		return config
+	}
+
+	imageEnv := make(map[string]bool, len(image.Env))
+	for _, env := range image.Env {
+		imageEnv[env] = true
+	}
+
+	env := config.Env
+	config.Env = nil
+	for _, value := range env {
+		if !imageEnv[value] {
+			config.Env = append(config.Env, value)
+		}
+	}
+
+	if equalStrings(config.Cmd, image.Cmd) {
+		config.Cmd = nil
+	}
+	if equalStrings(config.Entrypoint, image.Entrypoint) {
+		config.Entrypoint = nil
+	}
+	if config.WorkingDir == image.WorkingDir {
+		config.WorkingDir = ""
+	}
+	if config.User == image.User {
+		config.User = ""
+	}
+
+	ports := config.ExposedPorts
+	config.ExposedPorts = nil
+	for port := range ports {
+		if _, ok := image.ExposedPorts[port]; !ok {
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = make(nat.PortSet)
+			}
+			config.ExposedPorts[port] = struct{}{}
+		}
+	}
+
+	labels := config.Labels
+	config.Labels = nil
+	for key, value := range labels {
+		if imageValue, ok := image.Labels[key]; !ok || imageValue != value {
+			if config.Labels == nil {
+				config.Labels = make(map[string]string)
+			}
+			config.Labels[key] = value
+		}
+	}
+	return config
+}
+
+// recreateConfig returns the configuration of a container equivalent to
+// old but running img. Settings old inherited from its image, whose config
+// is image, are dropped in favour of img's. Anonymous volumes are carried
+// over as named mounts so that their data survives. The primary network is
+// attached at create time; the other networks are returned to be connected
+// before start.
+func recreateConfig(old types.ContainerJSON, image *container.Config,
+	img string) (*types.ContainerCreateConfig, map[string]*network.EndpointSettings) {
+	config := withoutImageDefaults(*old.Config, image)
+	hostConfig := *old.HostConfig
+	hostConfig.Mounts = append([]mount.Mount(nil), old.HostConfig.Mounts...)
+	extraNetworks := make(map[string]*network.EndpointSettings)
+
+	config.Image = img
+	if strings.HasPrefix(old.ID, config.Hostname) {
+		config.Hostname = ""
+	}
+
+	mounted := make(map[string]bool)
+	for _, bind := range hostConfig.Binds {
+		if parts := strings.Split(bind, ":"); len(parts) > 1 {
+			mounted[parts[1]] = true
+		}
+	}
+	for _, m := range hostConfig.Mounts {
+		mounted[m.Target] = true
+	}
+
+	for _, m := range old.Mounts {
+		if m.Type == mount.TypeVolume && !mounted[m.Destination] {
+			hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+				Type:     mount.TypeVolume,
+				Source:   m.Name,
+				Target:   m.Destination,
+				ReadOnly: !m.RW,
+			})
+		}
+	}
+
+	createConfig := &types.ContainerCreateConfig{
+		Name:       strings.TrimPrefix(old.Name, "/"),
+		Config:     &config,
+		HostConfig: &hostConfig,
+	}
+
+	if old.NetworkSettings == nil {
+		return createConfig, extraNetworks
+	}
+
+	primary := string(hostConfig.NetworkMode)
+	if primary == "default" {
+		primary = "bridge"
+	}
+
+	// NetworkMode names the primary network by name or by ID.
+	for name, endpoint := range old.NetworkSettings.Networks {
+		if name == primary || (endpoint.NetworkID != "" && endpoint.NetworkID == primary) {
+			createConfig.NetworkingConfig = &network.NetworkingConfig{
+				EndpointsConfig: map[string]*network.EndpointSettings{
+					name: endpointConfig(endpoint, old.ID)},
+			}
+		} else {
+			extraNetworks[name] = endpointConfig(endpoint, old.ID)
+		}
+	}
+	return createConfig, extraNetworks
+}
+
+// RecreateContainer replaces a container with a new one that has the same
+// configuration, host configuration, networks, and mounts, but runs a
+// freshly pulled or overridden image. The old container is stopped and
+// renamed while the new one is created and started, then removed. If any
+// step fails the new container is removed and the old one is restored.
+// RecreateContainer returns the ID of the new container.
+func (di *DockerInterface) RecreateContainer(ctx context.Context,
+	id string, opts RecreateOptions) (string, error) {
+	old, err := di.InspectContainer(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to recreate container: %s", err)
+	}
+
+	img := opts.Image
+	if img == "" {
+		img = old.Config.Image
+	}
+
+	if !opts.NoPull {
+		if err := di.PullImageWithOptions(ctx, img, opts.Pull); err != nil {
+			return "", fmt.Errorf("failed to recreate container: %s", err)
+		}
+	}
+
+	oldImage, _, err := di.Client.ImageInspectWithRaw(ctx, old.Image)
+	if err != nil {
+		return "", fmt.Errorf("failed to recreate container: %s", err)
+	}
+
+	config, extraNetworks := recreateConfig(old, oldImage.Config, img)
+	running := old.State != nil && old.State.Running
+
+	newID, err := di.replaceContainer(ctx, old, config, extraNetworks, running, opts.Timeout)
+	if err != nil {
+		if rollbackErr := di.rollbackRecreate(ctx, old, newID, running); rollbackErr != nil {
+			err = fmt.Errorf("%s (rollback failed: %s)", err, rollbackErr)
+		}
+		di.RefreshContainers(ctx)
+		return "", fmt.Errorf("failed to recreate container: %s", err)
+	}
+
+	if err := di.removeContainer(ctx, old.ID); err != nil {
+		return newID, err
+	}
+	return newID, di.RefreshContainers(ctx)
+}
+
+// replaceContainer stops and renames old, then creates and, if running is
+// set, starts its replacement. It returns the new container's ID, which is
+// set as soon as the container is created even if a later step fails.
+func (di *DockerInterface) replaceContainer(ctx context.Context, old types.ContainerJSON,
+	config *types.ContainerCreateConfig, extraNetworks map[string]*network.EndpointSettings,
+	running bool, timeout *time.Duration) (string, error) {
+	if running {
+		if err := di.stopContainer(ctx, old.ID, timeout); err != nil {
+			return "", err
+		}
+	}
+
+	if err := di.Client.ContainerRename(ctx, old.ID, config.Name+recreateSuffix); err != nil {
+		return "", fmt.Errorf("failed to rename container: %s", err)
+	}
+
+	response, err := di.Client.ContainerCreate(ctx, config.Config, config.HostConfig,
+		config.NetworkingConfig, nil, config.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create new container: %s", err)
+	}
+
+	for name, endpoint := range extraNetworks {
+		if err := di.Client.NetworkConnect(ctx, name, response.ID, endpoint); err != nil {
+			return response.ID, fmt.Errorf("failed to connect network: %s", err)
+		}
+	}
+
+	if running {
+		if err := di.startContainer(ctx, response.ID); err != nil {
+			return response.ID, err
+		}
+	}
+	return response.ID, nil
+}
+
+// rollbackRecreate removes the partially created container newID, if any,
+// and restores old's name and running state.
+func (di *DockerInterface) rollbackRecreate(ctx context.Context,
+	old types.ContainerJSON, newID string, running bool) error {
+	if newID != "" {
+		if err := di.removeContainer(ctx, newID); err != nil {
+			return err
+		}
+	}
+
+	current, err := di.Client.ContainerInspect(ctx, old.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch container: %s", err)
+	}
+
+	if current.Name != old.Name {
+		if err := di.Client.ContainerRename(ctx, old.ID,
+			strings.TrimPrefix(old.Name, "/")); err != nil {
+			return fmt.Errorf("failed to rename container: %s", err)
+		}
+	}
+
+	if running && (current.State == nil || !current.State.Running) {
+		return di.startContainer(ctx, old.ID)
+	}
+	return nil
+}
+
+// staleContainerRefs maps the ID of each of containers whose own image
+// reference is a stale ref in updates to that ref. A container created from
+// another tag of the same image is left out, as that tag is not outdated.
+func staleContainerRefs(containers []types.Container, updates []ImageUpdate) map[string]string {
+	stale := make(map[string]string)
+	for _, update := range updates {
+		if update.Stale {
+			stale[update.ID+" "+normalizeImageRef(update.Ref)] = update.Ref
+		}
+	}
+
+	refs := make(map[string]string)
+	for _, container := range containers {
+		if ref, ok := stale[container.ImageID+" "+normalizeImageRef(container.Image)]; ok {
+			refs[container.ID] = ref
+		}
+	}
+	return refs
+}
+
+// RecreateStaleContainers pulls every stale image in updates, as reported
+// by CheckImageUpdates, and recreates each running container created from
+// one of the stale refs on the new image, keeping the container's ref.
+// opts.Image and opts.NoPull are ignored.
+func (di *DockerInterface) RecreateStaleContainers(ctx context.Context,
+	updates []ImageUpdate, opts RecreateOptions) BulkResults {
+	results := make(BulkResults)
+	pulled := di.PullImageUpdates(ctx, updates, opts.Pull)
+
+	refs := staleContainerRefs(di.StaleContainers(updates), updates)
+	for id, ref := range refs {
+		if err := pulled[ref]; err != nil {
+			results[id] = err
+			continue
+		}
+
+		recreateOpts := opts
+		recreateOpts.Image, recreateOpts.NoPull = ref, true
+		_, results[id] = di.RecreateContainer(ctx, id, recreateOpts)
+	}
+	return results
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+// TestRecreateConfig
+func TestRecreateConfig(t *testing.T) {
+	old := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:   "0123456789abcdef",
+			Name: "/web",
+			HostConfig: &container.HostConfig{
+				NetworkMode: "f1e2d3",
+				Binds:       []string{"/srv/www:/usr/share/nginx/html:ro"},
+				Mounts:      []mount.Mount{{Type: mount.TypeTmpfs, Target: "/tmp"}},
+			},
+		},
+		Config: &container.Config{
+			Image:        "nginx:1.20",
+			Hostname:     "0123456789ab",
+			Env:          []string{"PATH=/usr/bin", "NGINX_VERSION=1.20.1", "MODE=prod"},
+			Cmd:          []string{"nginx", "-g", "daemon off;"},
+			ExposedPorts: nat.PortSet{"80/tcp": {}, "8443/tcp": {}},
+			Labels:       map[string]string{"maintainer": "nginx", "team": "web"},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/srv/www", Destination: "/usr/share/nginx/html"},
+			{Type: mount.TypeVolume, Name: "anon", Destination: "/var/cache/nginx", RW: true},
+		},
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"frontend": {NetworkID: "f1e2d3", Aliases: []string{"web", "0123456789ab"},
+				IPAddress: "172.18.0.2"},
+			"backend": {NetworkID: "b4c5d6", Aliases: []string{"api"}},
+		}},
+	}
+	image := &container.Config{
+		Env:          []string{"PATH=/usr/bin", "NGINX_VERSION=1.20.1"},
+		Cmd:          []string{"nginx", "-g", "daemon off;"},
+		ExposedPorts: nat.PortSet{"80/tcp": {}},
+		Labels:       map[string]string{"maintainer": "nginx"},
+	}
+
+	config, extra := recreateConfig(old, image, "nginx:1.21")
+
+	if config.Name != "web" || config.Config.Image != "nginx:1.21" || config.Config.Hostname != "" {
+		t.Errorf("got name %s, image %s, hostname %s", config.Name,
+			config.Config.Image, config.Config.Hostname)
+	}
+	if old.Config.Image != "nginx:1.20" {
+		t.Error("recreateConfig modified the old container's config")
+	}
+	if len(config.HostConfig.Mounts) != 2 || config.HostConfig.Mounts[1].Source != "anon" {
+		t.Errorf("got mounts %+v, want anonymous volume carried over", config.HostConfig.Mounts)
+	}
+	if len(old.HostConfig.Mounts) != 1 {
+		t.Error("recreateConfig modified the old container's mounts")
+	}
+
+	want := container.Config{Env: []string{"MODE=prod"}, ExposedPorts: nat.PortSet{"8443/tcp": {}},
+		Labels: map[string]string{"team": "web"}}
+	got := container.Config{Env: config.Config.Env, Cmd: config.Config.Cmd,
+		ExposedPorts: config.Config.ExposedPorts, Labels: config.Config.Labels}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got config %+v, want image defaults dropped: %+v", got, want)
+	}
+
+	frontend := config.NetworkingConfig.EndpointsConfig["frontend"]
+	if frontend == nil || len(frontend.Aliases) != 1 || frontend.IPAddress != "" {
+		t.Errorf("got primary endpoint %+v, want alias web only", frontend)
+	}
+	if len(extra) != 1 || extra["backend"] == nil {
+		t.Errorf("got extra networks %v, want backend", extra)
+	}
+}
+
+// TestStaleContainerRefs
+func TestStaleContainerRefs(t *testing.T) {
+	containers := []types.Container{
+		{ID: "a", Image: "nginx", ImageID: "sha256:old"},
+		{ID: "b", Image: "nginx:1.25", ImageID: "sha256:old"},
+		{ID: "c", Image: "docker.io/library/nginx:latest", ImageID: "sha256:new"},
+	}
+	updates := []ImageUpdate{
+		{ID: "sha256:old", Ref: "nginx:latest", Stale: true},
+		{ID: "sha256:old", Ref: "nginx:1.25"},
+	}
+
+	refs := staleContainerRefs(containers, updates)
+	if want := map[string]string{"a": "nginx:latest"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("got refs %v, want %v", refs, want)
+	}
+}
+
+// TestRecreateContainer
+func TestRecreateContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "nginx",
+		"env": "IS_TEST=TRUE"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+	di.StartContainer(ctx, conID)
+
+	newID, err := di.RecreateContainer(ctx, conID, RecreateOptions{Image: "nginx:alpine"})
+	if err != nil {
+		t.Logf("got error recreating container: %s", err)
+		t.FailNow()
+	}
+	defer di.RemoveContainer(ctx, newID)
+
+	inspect, err := di.InspectContainer(ctx, "test_container")
+	if err != nil {
+		t.Logf("got error inspecting container: %s", err)
+		t.FailNow()
+	}
+	if inspect.ID != newID || inspect.Config.Image != "nginx:alpine" || !inspect.State.Running {
+		t.Errorf("got container %s running %s, want %s running nginx:alpine",
+			inspect.ID, inspect.Config.Image, newID)
+	}
+	if _, err := di.InspectContainer(ctx, conID); err == nil {
+		t.Error("expected old container to be removed")
+	}
+
+	if _, err := di.RecreateContainer(ctx, newID, RecreateOptions{
+		Image: "no_such_image", NoPull: true}); err == nil {
+		t.Error("expected error recreating with missing image")
+	}
+	if restored, _ := di.InspectContainer(ctx, "test_container"); restored.ID != newID {
+		t.Error("expected container to be restored after failed recreate")
+	}
+}
@@ -0,0 +1,410 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Host serving the registry API for Docker Hub images.
+const dockerHubRegistryHost = "registry-1.docker.io"
+
+// DefaultTagPageSize is the number of tags requested per page when
+// TagListOptions.PageSize is not set.
+const DefaultTagPageSize = 100
+
+// Manifest media types understood by ImageManifest.
+const (
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// Matches the parameters of a WWW-Authenticate challenge.
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Matches the next page URL in a Link header.
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// RegistryClient talks to a single registry through the Registry v2 API.
+// A nil HTTPClient uses http.DefaultClient.
+type RegistryClient struct {
+	// Host is the registry's host and optional port.
+	Host string
+	// Insecure uses plain HTTP instead of HTTPS.
+	Insecure   bool
+	Auth       types.AuthConfig
+	HTTPClient *http.Client
+
+	// tokens caches bearer tokens by scope.
+	tokens map[string]string
+}
+
+// TagListOptions controls how tags are listed.
+type TagListOptions struct {
+	// PageSize is the number of tags requested from the registry at a time.
+	PageSize int
+	// Last starts the listing after this tag.
+	Last string
+	// Limit is the maximum number of tags returned; zero returns all tags.
+	Limit int
+}
+
+// ManifestInfo describes the manifest a registry serves for an image
+// reference.
+type ManifestInfo struct {
+	Digest    string
+	MediaType string
+	// Platforms lists every platform in a multi-platform image, or the
+	// single platform of a plain image.
+	Platforms []specs.Platform
+}
+
+// manifest holds the fields of a manifest or manifest list used to find
+// an image's platforms.
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Platform specs.Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+// NewRegistryClient returns a client for the registry at host, such as
+// "registry.example.com" or "localhost:5000". Registries on localhost are
+// reached over plain HTTP.
+func NewRegistryClient(host string, auth types.AuthConfig) *RegistryClient {
+	if host == "" || host == "docker.io" || host == "index.docker.io" {
+		host = dockerHubRegistryHost
+	}
+
+	hostname := strings.SplitN(host, ":", 2)[0]
+	return &RegistryClient{
+		Host:       host,
+		Insecure:   hostname == "localhost" || hostname == "127.0.0.1",
+		Auth:       auth,
+		HTTPClient: http.DefaultClient,
+		tokens:     make(map[string]string),
+	}
+}
+
+// registryClient returns a RegistryClient for the registry holding ref,
+// with credentials resolved the same way as PullImage, and the
+// repository path within that registry.
+func (di *DockerInterface) registryClient(ref string) (*RegistryClient, reference.Named, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid image reference %s: %s", ref, err)
+	}
+
+	auth, err := di.ResolveAuth(reference.Domain(named))
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewRegistryClient(reference.Domain(named), auth), named, nil
+}
+
+// url returns the absolute URL for ref on the registry. ref is usually a
+// path, but may be a full URL, as in a Link header.
+func (rc *RegistryClient) url(ref string) (string, error) {
+	scheme := "https"
+	if rc.Insecure {
+		scheme = "http"
+	}
+
+	target, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	base := &url.URL{Scheme: scheme, Host: rc.Host, Path: "/"}
+	return base.ResolveReference(target).String(), nil
+}
+
+// httpClient returns the client used for requests to the registry.
+func (rc *RegistryClient) httpClient() *http.Client {
+	if rc.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return rc.HTTPClient
+}
+
+// fetchToken requests a bearer token for the challenge in header.
+func (rc *RegistryClient) fetchToken(ctx context.Context, header string) (string, error) {
+	params := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(header, -1) {
+		params[match[1]] = match[2]
+	}
+
+	if params["realm"] == "" {
+		return "", fmt.Errorf("invalid auth challenge %q", header)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		query.Set("scope", params["scope"])
+	}
+
+	var req *http.Request
+	var err error
+
+	if rc.Auth.IdentityToken != "" {
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", rc.Auth.IdentityToken)
+		query.Set("client_id", "dockland")
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, params["realm"],
+			strings.NewReader(query.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet,
+			params["realm"]+"?"+query.Encode(), nil)
+		if err == nil && rc.Auth.Username != "" {
+			req.SetBasicAuth(rc.Auth.Username, rc.Auth.Password)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := rc.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+// get performs a GET request against the registry, authenticating with
+// basic auth or a bearer token as the registry demands.
+func (rc *RegistryClient) get(ctx context.Context, scope, path string,
+	accept ...string) (*http.Response, error) {
+	target, err := rc.url(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mediaType := range accept {
+			req.Header.Add("Accept", mediaType)
+		}
+		if token := rc.tokens[scope]; token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if rc.Auth.Username != "" {
+			req.SetBasicAuth(rc.Auth.Username, rc.Auth.Password)
+		}
+
+		resp, err := rc.httpClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 ||
+			!strings.HasPrefix(challenge, "Bearer ") {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		token, err := rc.fetchToken(ctx, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate with %s: %s", rc.Host, err)
+		}
+		if rc.tokens == nil {
+			rc.tokens = make(map[string]string)
+		}
+		rc.tokens[scope] = token
+	}
+	return nil, fmt.Errorf("failed to authenticate with %s", rc.Host)
+}
+
+// checkResponse returns an error describing an unsuccessful response.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("registry returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// ListTags returns the tags of repository, such as "library/nginx",
+// following the registry's pagination until opts.Limit tags are found or
+// no pages remain.
+func (rc *RegistryClient) ListTags(ctx context.Context,
+	repository string, opts TagListOptions) ([]string, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultTagPageSize
+	}
+
+	query := url.Values{"n": {fmt.Sprint(pageSize)}}
+	if opts.Last != "" {
+		query.Set("last", opts.Last)
+	}
+
+	var tags []string
+	scope := "repository:" + repository + ":pull"
+	path := "/v2/" + repository + "/tags/list?" + query.Encode()
+
+	for path != "" {
+		resp, err := rc.get(ctx, scope, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %s", err)
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = checkResponse(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %s", err)
+		}
+
+		tags = append(tags, page.Tags...)
+		if opts.Limit > 0 && len(tags) >= opts.Limit {
+			return tags[:opts.Limit], nil
+		}
+
+		path = ""
+		if match := nextLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			path = match[1]
+		}
+	}
+	return tags, nil
+}
+
+// Manifest returns the digest, media type, and platforms of the manifest
+// for repository at tagOrDigest.
+func (rc *RegistryClient) Manifest(ctx context.Context,
+	repository, tagOrDigest string) (ManifestInfo, error) {
+	scope := "repository:" + repository + ":pull"
+
+	resp, err := rc.get(ctx, scope, "/v2/"+repository+"/manifests/"+tagOrDigest,
+		mediaTypeManifestList, mediaTypeOCIIndex, mediaTypeManifest, mediaTypeOCIManifest)
+	if err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to fetch manifest: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to fetch manifest: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to fetch manifest: %s", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to parse manifest: %s", err)
+	}
+
+	info := ManifestInfo{
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		MediaType: resp.Header.Get("Content-Type"),
+	}
+	if info.Digest == "" {
+		info.Digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+	if m.MediaType != "" {
+		info.MediaType = m.MediaType
+	}
+
+	for _, entry := range m.Manifests {
+		info.Platforms = append(info.Platforms, entry.Platform)
+	}
+	if m.Config.Digest == "" {
+		return info, nil
+	}
+
+	// A single platform image records its platform in the config blob.
+	resp, err = rc.get(ctx, scope, "/v2/"+repository+"/blobs/"+m.Config.Digest)
+	if err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to fetch image config: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var platform specs.Platform
+	err = checkResponse(resp)
+	if err == nil {
+		err = json.NewDecoder(resp.Body).Decode(&platform)
+	}
+	if err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to fetch image config: %s", err)
+	}
+
+	info.Platforms = append(info.Platforms, platform)
+	return info, nil
+}
+
+// ListImageTags returns the tags available in the registry for the
+// repository of ref, such as "nginx" or "registry.example.com/team/app".
+func (di *DockerInterface) ListImageTags(ctx context.Context,
+	ref string, opts TagListOptions) ([]string, error) {
+	rc, named, err := di.registryClient(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %s", err)
+	}
+	return rc.ListTags(ctx, reference.Path(named), opts)
+}
+
+// ImageManifest returns the registry's manifest digest and platform list
+// for ref. A ref without a tag or digest refers to the latest tag.
+func (di *DockerInterface) ImageManifest(ctx context.Context, ref string) (ManifestInfo, error) {
+	rc, named, err := di.registryClient(ref)
+	if err != nil {
+		return ManifestInfo{}, fmt.Errorf("failed to fetch manifest: %s", err)
+	}
+
+	tagOrDigest := "latest"
+	if digested, ok := named.(reference.Digested); ok {
+		tagOrDigest = digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		tagOrDigest = tagged.Tag()
+	}
+	return rc.Manifest(ctx, reference.Path(named), tagOrDigest)
+}
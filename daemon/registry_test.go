@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Start a registry stand-in serving one repository with five tags behind
+// token authentication. The first page links to the next by path and later
+// pages by absolute URL.
+func newTestRegistry() *httptest.Server {
+	tags := []string{"1.0", "1.1", "2.0", "2.1", "latest"}
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token":"secret-token"}`)
+	})
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="test",scope="repository:team/app:pull"`,
+				server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/team/app/tags/list":
+			start := 0
+			for i, tag := range tags {
+				if tag == r.URL.Query().Get("last") {
+					start = i + 1
+				}
+			}
+			end := start + 2
+			if end < len(tags) {
+				next := fmt.Sprintf("/v2/team/app/tags/list?n=2&last=%s", tags[end-1])
+				if start > 0 {
+					next = server.URL + next
+				}
+				w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+			} else {
+				end = len(tags)
+			}
+			fmt.Fprintf(w, `{"name":"team/app","tags":["%s"]}`,
+				strings.Join(tags[start:end], `","`))
+		case r.URL.Path == "/v2/team/app/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", "sha256:list")
+			fmt.Fprintf(w, `{"mediaType":"%s","manifests":[`+
+				`{"platform":{"architecture":"amd64","os":"linux"}},`+
+				`{"platform":{"architecture":"arm64","os":"linux","variant":"v8"}}]}`,
+				mediaTypeManifestList)
+		case r.URL.Path == "/v2/team/app/manifests/1.0":
+			w.Header().Set("Docker-Content-Digest", "sha256:single")
+			fmt.Fprintf(w, `{"mediaType":"%s","config":{"digest":"sha256:config"}}`,
+				mediaTypeManifest)
+		case r.URL.Path == "/v2/team/app/blobs/sha256:config":
+			fmt.Fprint(w, `{"architecture":"amd64","os":"linux"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	server = httptest.NewServer(mux)
+	return server
+}
+
+// TestListImageTags
+func TestListImageTags(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	di := &DockerInterface{}
+	di.SetAuth(host, types.AuthConfig{Username: "user", Password: "pass"})
+
+	tags, err := di.ListImageTags(context.TODO(), host+"/team/app", TagListOptions{PageSize: 2})
+	if err != nil {
+		t.Logf("got error listing tags: %s", err)
+		t.FailNow()
+	}
+	if want := []string{"1.0", "1.1", "2.0", "2.1", "latest"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+
+	tags, _ = di.ListImageTags(context.TODO(), host+"/team/app",
+		TagListOptions{PageSize: 2, Last: "1.1", Limit: 1})
+	if want := []string{"2.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+
+	if _, err := (&DockerInterface{}).ListImageTags(context.TODO(), host+"/team/app",
+		TagListOptions{}); err == nil {
+		t.Error("expected error listing tags without credentials")
+	}
+}
+
+// TestRegistryClientLiteral
+func TestRegistryClientLiteral(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+
+	rc := &RegistryClient{Host: strings.TrimPrefix(server.URL, "http://"), Insecure: true,
+		Auth: types.AuthConfig{Username: "user", Password: "pass"}}
+
+	tags, err := rc.ListTags(context.TODO(), "team/app", TagListOptions{PageSize: 2})
+	if err != nil {
+		t.Logf("got error listing tags: %s", err)
+		t.FailNow()
+	}
+	if len(tags) != 5 {
+		t.Errorf("got tags %v, want 5 tags", tags)
+	}
+}
+
+// TestImageManifest
+func TestImageManifest(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	di := &DockerInterface{}
+	di.SetAuth(host, types.AuthConfig{Username: "user", Password: "pass"})
+
+	info, err := di.ImageManifest(context.TODO(), host+"/team/app")
+	if err != nil {
+		t.Logf("got error fetching manifest: %s", err)
+		t.FailNow()
+	}
+	if info.Digest != "sha256:list" || len(info.Platforms) != 2 ||
+		info.Platforms[1].Variant != "v8" {
+		t.Errorf("got manifest %+v, want list with two platforms", info)
+	}
+
+	info, err = di.ImageManifest(context.TODO(), host+"/team/app:1.0")
+	if err != nil {
+		t.Logf("got error fetching manifest: %s", err)
+		t.FailNow()
+	}
+	if info.Digest != "sha256:single" || len(info.Platforms) != 1 ||
+		info.Platforms[0].Architecture != "amd64" {
+		t.Errorf("got manifest %+v, want single amd64 platform", info)
+	}
+
+	if _, err := di.ImageManifest(context.TODO(), host+"/team/app:9.9"); err == nil {
+		t.Error("expected error fetching missing manifest")
+	}
+}
@@ -0,0 +1,276 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+// ResourceKind identifies the type of a Docker resource.
+type ResourceKind string
+
+// The kinds of resources tracked by a DockerInterface.
+const (
+	KindContainer ResourceKind = "container"
+	KindImage     ResourceKind = "image"
+	KindNetwork   ResourceKind = "network"
+	KindVolume    ResourceKind = "volume"
+	KindDaemon    ResourceKind = "daemon"
+)
+
+// shortIDLength is the number of ID characters shown by the Docker CLI.
+const shortIDLength = 12
+
+// Resource identifies a single container, image, network, volume, or the
+// daemon itself.
+type Resource struct {
+	Kind ResourceKind
+	ID   string
+	Name string
+}
+
+// String returns the resource's kind and a human readable name.
+func (r Resource) String() string {
+	if r.Name != "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s", r.Kind, ShortID(r.ID))
+}
+
+// ResourceNotFoundError is returned when no cached resource matches the
+// user's input.
+type ResourceNotFoundError struct {
+	Kind  ResourceKind
+	Input string
+}
+
+// Error is called when a lookup finds no matching resource.
+func (r *ResourceNotFoundError) Error() string {
+	if r.Kind == "" {
+		return fmt.Sprintf("no resource matches %q", r.Input)
+	}
+	return fmt.Sprintf("no %s matches %q", r.Kind, r.Input)
+}
+
+// AmbiguousResourceError is returned when the user's input matches more
+// than one cached resource.
+type AmbiguousResourceError struct {
+	Input      string
+	Candidates []Resource
+}
+
+// Error is called when a lookup finds several matching resources.
+func (r *AmbiguousResourceError) Error() string {
+	names := make([]string, 0, len(r.Candidates))
+
+	for _, candidate := range r.Candidates {
+		names = append(names, candidate.String())
+	}
+	return fmt.Sprintf("%q is ambiguous, could be: %s", r.Input, strings.Join(names, ", "))
+}
+
+// ShortID returns the abbreviated form of a container, image, or network ID
+// without any algorithm prefix.
+func ShortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+
+	if len(id) > shortIDLength {
+		return id[:shortIDLength]
+	}
+	return id
+}
+
+// containerName returns the primary name of a container without the
+// leading slash added by the daemon.
+func containerName(container types.Container) string {
+	if len(container.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(container.Names[0], "/")
+}
+
+// imageName returns the first tag of an image, or an empty string for an
+// untagged image.
+func imageName(image types.ImageSummary) string {
+	for _, tag := range image.RepoTags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// normalizeImageRef returns ref in its familiar tagged form, so that
+// "nginx" and "docker.io/library/nginx:latest" both become "nginx:latest".
+func normalizeImageRef(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	return reference.FamiliarString(reference.TagNameOnly(named))
+}
+
+// isIDPrefix reports whether input abbreviates id. Prefixes may include
+// the "sha256:" algorithm.
+func isIDPrefix(id, input string) bool {
+	if input == "" {
+		return false
+	}
+	return strings.HasPrefix(id, input) ||
+		strings.HasPrefix(strings.TrimPrefix(id, "sha256:"), input)
+}
+
+// resolveMatches returns the single resource in exact if there is one,
+// otherwise the single resource in prefix. It returns an error if either
+// set is ambiguous or both are empty.
+func resolveMatches(kind ResourceKind, input string,
+	exact, prefix []Resource) (Resource, error) {
+	for _, matches := range [][]Resource{exact, prefix} {
+		if len(matches) == 1 {
+			return matches[0], nil
+		} else if len(matches) > 1 {
+			return Resource{}, &AmbiguousResourceError{input, matches}
+		}
+	}
+	return Resource{}, &ResourceNotFoundError{kind, input}
+}
+
+// ResolveContainer returns the cached container matching input, which may
+// be a full ID, a container name, or a unique ID prefix.
+func (di *DockerInterface) ResolveContainer(input string) (types.Container, error) {
+	var exact, prefix []Resource
+	byID := make(map[string]types.Container)
+
+	for _, container := range di.Containers {
+		resource := Resource{KindContainer, container.ID, containerName(container)}
+		byID[container.ID] = container
+
+		if container.ID == input || resource.Name == strings.TrimPrefix(input, "/") {
+			exact = append(exact, resource)
+		} else if isIDPrefix(container.ID, input) {
+			prefix = append(prefix, resource)
+		}
+	}
+
+	resource, err := resolveMatches(KindContainer, input, exact, prefix)
+	if err != nil {
+		return types.Container{}, err
+	}
+	return byID[resource.ID], nil
+}
+
+// ResolveImage returns the cached image matching input, which may be a full
+// ID, an image reference such as "nginx" or "nginx:1.21", a digest
+// reference, or a unique ID prefix.
+func (di *DockerInterface) ResolveImage(input string) (types.ImageSummary, error) {
+	var exact, prefix []Resource
+	byID := make(map[string]types.ImageSummary)
+	normalized := normalizeImageRef(input)
+
+	for _, image := range di.Images {
+		resource := Resource{KindImage, image.ID, imageName(image)}
+		byID[image.ID] = image
+
+		if image.ID == input || image.ID == "sha256:"+input ||
+			containsString(image.RepoTags, normalized) ||
+			containsString(image.RepoDigests, input) {
+			exact = append(exact, resource)
+		} else if isIDPrefix(image.ID, input) {
+			prefix = append(prefix, resource)
+		}
+	}
+
+	resource, err := resolveMatches(KindImage, input, exact, prefix)
+	if err != nil {
+		return types.ImageSummary{}, err
+	}
+	return byID[resource.ID], nil
+}
+
+// ResolveNetwork returns the cached network matching input, which may be a
+// full ID, a network name, or a unique ID prefix.
+func (di *DockerInterface) ResolveNetwork(input string) (types.NetworkResource, error) {
+	var exact, prefix []Resource
+	byID := make(map[string]types.NetworkResource)
+
+	for _, network := range di.Networks {
+		resource := Resource{KindNetwork, network.ID, network.Name}
+		byID[network.ID] = network
+
+		if network.ID == input || network.Name == input {
+			exact = append(exact, resource)
+		} else if isIDPrefix(network.ID, input) {
+			prefix = append(prefix, resource)
+		}
+	}
+
+	resource, err := resolveMatches(KindNetwork, input, exact, prefix)
+	if err != nil {
+		return types.NetworkResource{}, err
+	}
+	return byID[resource.ID], nil
+}
+
+// ResolveVolume returns the cached volume named input.
+func (di *DockerInterface) ResolveVolume(input string) (*types.Volume, error) {
+	for _, volume := range di.Volumes {
+		if volume.Name == input {
+			return volume, nil
+		}
+	}
+	return nil, &ResourceNotFoundError{KindVolume, input}
+}
+
+// Resolve returns the single cached container, image, network, or volume
+// matching input. An AmbiguousResourceError listing every candidate is
+// returned if input matches resources of more than one kind.
+func (di *DockerInterface) Resolve(input string) (Resource, error) {
+	var matches []Resource
+
+	if container, err := di.ResolveContainer(input); err == nil {
+		matches = append(matches, Resource{KindContainer, container.ID, containerName(container)})
+	} else if ambiguous, ok := err.(*AmbiguousResourceError); ok {
+		matches = append(matches, ambiguous.Candidates...)
+	}
+
+	if image, err := di.ResolveImage(input); err == nil {
+		matches = append(matches, Resource{KindImage, image.ID, imageName(image)})
+	} else if ambiguous, ok := err.(*AmbiguousResourceError); ok {
+		matches = append(matches, ambiguous.Candidates...)
+	}
+
+	if network, err := di.ResolveNetwork(input); err == nil {
+		matches = append(matches, Resource{KindNetwork, network.ID, network.Name})
+	} else if ambiguous, ok := err.(*AmbiguousResourceError); ok {
+		matches = append(matches, ambiguous.Candidates...)
+	}
+
+	if volume, err := di.ResolveVolume(input); err == nil {
+		matches = append(matches, Resource{KindVolume, volume.Name, volume.Name})
+	}
+
+	switch len(matches) {
+	case 0:
+		return Resource{}, &ResourceNotFoundError{"", input}
+	case 1:
+		return matches[0], nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].String() < matches[j].String()
+	})
+	return Resource{}, &AmbiguousResourceError{input, matches}
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Build an interface with a fixed set of cached resources.
+func newResolveInterface() *DockerInterface {
+	return &DockerInterface{
+		Containers: []types.Container{
+			{ID: "abc123aaaaaa", Names: []string{"/web"}, Image: "nginx"},
+			{ID: "abc456bbbbbb", Names: []string{"/db"}, Image: "postgres"},
+		},
+		Images: []types.ImageSummary{
+			{ID: "sha256:def789cccccc", RepoTags: []string{"nginx:latest"}},
+			{ID: "sha256:fed000dddddd", RepoTags: []string{"web:latest"}},
+		},
+		Networks: []types.NetworkResource{
+			{ID: "0a1b2c3d4e5f", Name: "bridge"},
+		},
+		Volumes: []*types.Volume{
+			{Name: "pgdata"},
+		},
+	}
+}
+
+// TestResolveContainer
+func TestResolveContainer(t *testing.T) {
+	di := newResolveInterface()
+	tables := []struct {
+		input string
+		id    string
+	}{
+		{"abc123aaaaaa", "abc123aaaaaa"},
+		{"web", "abc123aaaaaa"},
+		{"/db", "abc456bbbbbb"},
+		{"abc4", "abc456bbbbbb"},
+	}
+
+	for _, table := range tables {
+		container, err := di.ResolveContainer(table.input)
+		if err != nil {
+			t.Errorf("got error resolving %s: %s", table.input, err)
+		} else if container.ID != table.id {
+			t.Errorf("resolved %s to %s, want %s", table.input, container.ID, table.id)
+		}
+	}
+
+	if _, err := di.ResolveContainer("abc"); err == nil {
+		t.Error("expected error resolving ambiguous prefix")
+	} else if ambiguous, ok := err.(*AmbiguousResourceError); !ok || len(ambiguous.Candidates) != 2 {
+		t.Errorf("got error %s, want ambiguity between 2 containers", err)
+	}
+	if _, err := di.ResolveContainer("no_such_container"); err == nil {
+		t.Error("expected error resolving missing container")
+	}
+}
+
+// TestResolveImage
+func TestResolveImage(t *testing.T) {
+	di := newResolveInterface()
+	tables := []string{"nginx", "nginx:latest", "docker.io/library/nginx", "def7",
+		"sha256:def789cccccc"}
+
+	for _, input := range tables {
+		image, err := di.ResolveImage(input)
+		if err != nil {
+			t.Errorf("got error resolving %s: %s", input, err)
+		} else if image.ID != "sha256:def789cccccc" {
+			t.Errorf("resolved %s to %s, want sha256:def789cccccc", input, image.ID)
+		}
+	}
+}
+
+// TestResolve
+func TestResolve(t *testing.T) {
+	di := newResolveInterface()
+
+	if resource, err := di.Resolve("pgdata"); err != nil || resource.Kind != KindVolume {
+		t.Errorf("got %v, %v resolving pgdata, want volume", resource, err)
+	}
+	if resource, err := di.Resolve("bridge"); err != nil || resource.Kind != KindNetwork {
+		t.Errorf("got %v, %v resolving bridge, want network", resource, err)
+	}
+	if _, err := di.Resolve("web"); err == nil {
+		t.Error("expected error resolving name shared by container and image")
+	}
+}
@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Score adjustments used when ranking fuzzy search matches.
+const (
+	scoreMatch       = 1
+	scoreConsecutive = 5
+	scoreBoundary    = 8
+	scoreExact       = 50
+	penaltyGap       = 1
+)
+
+// SearchResult is a resource matched by Search along with the text that
+// matched and its relevance score. Higher scores are better matches.
+type SearchResult struct {
+	Resource
+	Match string
+	Score int
+}
+
+// isBoundary reports whether r separates words in resource names.
+func isBoundary(r rune) bool {
+	return strings.ContainsRune("/-_.: @", r)
+}
+
+// fuzzyScore reports whether every character of pattern appears in text in
+// order, ignoring case, and scores how closely they match. Consecutive
+// characters and characters starting a word score higher; characters
+// skipped between matches lower the score.
+func fuzzyScore(pattern, text string) (int, bool) {
+	pattern, text = strings.ToLower(pattern), strings.ToLower(text)
+	if pattern == "" {
+		return 0, false
+	}
+	if pattern == text {
+		return scoreExact + len(pattern)*(scoreMatch+scoreConsecutive), true
+	}
+
+	score, gap := 0, 0
+	matched, prevMatched := false, false
+	prev := rune(-1)
+	next, size := utf8.DecodeRuneInString(pattern)
+
+	for _, r := range text {
+		if pattern != "" && r == next {
+			score += scoreMatch
+			if prevMatched {
+				score += scoreConsecutive
+			}
+			if prev == -1 || isBoundary(prev) {
+				score += scoreBoundary
+			}
+			if matched {
+				score -= gap * penaltyGap
+			}
+
+			matched, prevMatched, gap = true, true, 0
+			pattern = pattern[size:]
+			next, size = utf8.DecodeRuneInString(pattern)
+		} else {
+			prevMatched = false
+			gap++
+		}
+		prev = r
+	}
+
+	if pattern != "" {
+		return 0, false
+	}
+	return score, true
+}
+
+// searchTexts returns every cached resource with the strings a user might
+// type to find it.
+func (di *DockerInterface) searchTexts() map[Resource][]string {
+	texts := make(map[Resource][]string)
+
+	for _, container := range di.Containers {
+		resource := Resource{KindContainer, container.ID, containerName(container)}
+		names := make([]string, 0, len(container.Names)+2)
+
+		for _, name := range container.Names {
+			names = append(names, strings.TrimPrefix(name, "/"))
+		}
+		texts[resource] = append(names, ShortID(container.ID), container.Image)
+	}
+
+	for _, image := range di.Images {
+		resource := Resource{KindImage, image.ID, imageName(image)}
+		texts[resource] = append([]string{ShortID(image.ID)}, image.RepoTags...)
+	}
+
+	for _, network := range di.Networks {
+		resource := Resource{KindNetwork, network.ID, network.Name}
+		texts[resource] = []string{network.Name, ShortID(network.ID)}
+	}
+
+	for _, volume := range di.Volumes {
+		resource := Resource{KindVolume, volume.Name, volume.Name}
+		texts[resource] = []string{volume.Name}
+	}
+
+	if di.Info.ID != "" {
+		resource := Resource{KindDaemon, di.Info.ID, di.Info.Name}
+		texts[resource] = []string{di.Info.Name, "docker"}
+	}
+	return texts
+}
+
+// Search returns the cached resources fuzzily matching query, best matches
+// first. Containers, images, networks, volumes, and the daemon itself are
+// all searched. At most limit results are returned; a limit of zero or
+// less returns every match.
+func (di *DockerInterface) Search(query string, limit int) []SearchResult {
+	var results []SearchResult
+
+	for resource, texts := range di.searchTexts() {
+		var best SearchResult
+		found := false
+
+		for _, text := range texts {
+			if score, ok := fuzzyScore(query, text); ok && (!found || score > best.Score) {
+				best, found = SearchResult{resource, text, score}, true
+			}
+		}
+		if found {
+			results = append(results, best)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if len(results[i].Match) != len(results[j].Match) {
+			return len(results[i].Match) < len(results[j].Match)
+		}
+		return results[i].String() < results[j].String()
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,44 @@
+package daemon
+
+import "testing"
+
+// TestFuzzyScore
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("ngx", "nginx"); !ok {
+		t.Error("expected ngx to match nginx")
+	}
+	if _, ok := fuzzyScore("xng", "nginx"); ok {
+		t.Error("expected xng not to match nginx")
+	}
+
+	exact, _ := fuzzyScore("web", "web")
+	prefix, _ := fuzzyScore("web", "webserver")
+	scattered, _ := fuzzyScore("web", "wide_eb")
+	if !(exact > prefix && prefix > scattered) {
+		t.Errorf("got scores %d, %d, %d, want descending", exact, prefix, scattered)
+	}
+}
+
+// TestSearch
+func TestSearch(t *testing.T) {
+	di := newResolveInterface()
+
+	results := di.Search("ngin", 0)
+	if len(results) < 2 {
+		t.Logf("got %d results, want at least 2", len(results))
+		t.FailNow()
+	}
+	if results[0].Match != "nginx" {
+		t.Errorf("got best match %s, want nginx", results[0].Match)
+	}
+	if results[len(results)-1].Kind != KindImage {
+		t.Errorf("got worst match %s, want image nginx:latest", results[len(results)-1].Resource)
+	}
+
+	if results := di.Search("pg", 1); len(results) != 1 || results[0].Kind != KindVolume {
+		t.Errorf("got %v, want only volume pgdata", results)
+	}
+	if results := di.Search("zzz", 0); len(results) != 0 {
+		t.Errorf("got %d results, want 0", len(results))
+	}
+}
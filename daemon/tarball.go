@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Prefixes of the lines the daemon reports for each image it loads.
+const (
+	loadedImagePrefix   = "Loaded image: "
+	loadedImageIDPrefix = "Loaded image ID: "
+)
+
+// TarballOptions controls how image and container tarballs are written
+// and read.
+type TarballOptions struct {
+	// Gzip compresses tarballs written by SaveImages and ExportContainer.
+	// Tarballs read by LoadImages and ImportImage may always be gzip
+	// compressed; the daemon detects compression itself.
+	Gzip bool
+	// Progress, if set, is called with the total number of bytes copied
+	// so far, before compression on write.
+	Progress func(bytes int64)
+}
+
+// progressReader reports the number of bytes read through it.
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	progress func(int64)
+}
+
+// Read reads from the underlying reader and reports progress.
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.reader.Read(p)
+	if n > 0 && pr.progress != nil {
+		pr.total += int64(n)
+		pr.progress(pr.total)
+	}
+	return n, err
+}
+
+// writeTarball copies content to w, compressing it if opts.Gzip is set.
+func writeTarball(w io.Writer, content io.Reader, opts TarballOptions) error {
+	reader := &progressReader{reader: content, progress: opts.Progress}
+
+	if !opts.Gzip {
+		_, err := io.Copy(w, reader)
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, reader); err != nil {
+		gz.Close()
+		return err
+	}
+	return gz.Close()
+}
+
+// SaveImages writes the images in refs, with all their tags and layers, to
+// w as a single tarball that can be read by LoadImages.
+func (di *DockerInterface) SaveImages(ctx context.Context,
+	refs []string, w io.Writer, opts TarballOptions) error {
+	response, err := di.Client.ImageSave(ctx, refs)
+	if err != nil {
+		return fmt.Errorf("failed to save images: %s", err)
+	}
+	defer response.Close()
+
+	if err := writeTarball(w, response, opts); err != nil {
+		return fmt.Errorf("failed to save images: %s", err)
+	}
+	return nil
+}
+
+// LoadImages loads the images in a tarball written by SaveImages or
+// docker save and returns the references of the loaded images.
+func (di *DockerInterface) LoadImages(ctx context.Context,
+	r io.Reader, opts TarballOptions) ([]string, error) {
+	input := &progressReader{reader: r, progress: opts.Progress}
+
+	response, err := di.Client.ImageLoad(ctx, input, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load images: %s", err)
+	}
+	defer response.Body.Close()
+
+	var loaded []string
+	if err := decodeStream(response.Body, "load", func(msg streamMessage) {
+		for _, line := range strings.Split(msg.Stream, "\n") {
+			if strings.HasPrefix(line, loadedImagePrefix) {
+				loaded = append(loaded, strings.TrimPrefix(line, loadedImagePrefix))
+			} else if strings.HasPrefix(line, loadedImageIDPrefix) {
+				loaded = append(loaded, strings.TrimPrefix(line, loadedImageIDPrefix))
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return loaded, di.RefreshImages(ctx)
+}
+
+// ExportContainer writes a container's filesystem to w as a flat tarball
+// that can be read by ImportImage.
+func (di *DockerInterface) ExportContainer(ctx context.Context,
+	id string, w io.Writer, opts TarballOptions) error {
+	response, err := di.Client.ContainerExport(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to export container: %s", err)
+	}
+	defer response.Close()
+
+	if err := writeTarball(w, response, opts); err != nil {
+		return fmt.Errorf("failed to export container: %s", err)
+	}
+	return nil
+}
+
+// ImportImage creates a single layer image from a filesystem tarball, such
+// as one written by ExportContainer, tags it as ref if ref is not empty,
+// and returns the new image's ID.
+func (di *DockerInterface) ImportImage(ctx context.Context,
+	r io.Reader, ref string, opts TarballOptions) (string, error) {
+	source := types.ImageImportSource{
+		Source:     &progressReader{reader: r, progress: opts.Progress},
+		SourceName: "-",
+	}
+
+	response, err := di.Client.ImageImport(ctx, source, ref, types.ImageImportOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to import image: %s", err)
+	}
+	defer response.Close()
+
+	var id string
+	if err := decodeStream(response, "import", func(msg streamMessage) {
+		if strings.HasPrefix(msg.Status, "sha256:") {
+			id = msg.Status
+		}
+	}); err != nil {
+		return "", err
+	}
+	return id, di.RefreshImages(ctx)
+}
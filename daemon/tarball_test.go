@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// TestWriteTarball
+func TestWriteTarball(t *testing.T) {
+	content := strings.Repeat("layer data ", 1000)
+
+	var progress int64
+	var buf bytes.Buffer
+	opts := TarballOptions{Gzip: true, Progress: func(n int64) { progress = n }}
+
+	if err := writeTarball(&buf, strings.NewReader(content), opts); err != nil {
+		t.Logf("got error writing tarball: %s", err)
+		t.FailNow()
+	}
+	if progress != int64(len(content)) {
+		t.Errorf("got progress %d, want %d", progress, len(content))
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Logf("got error reading gzip: %s", err)
+		t.FailNow()
+	}
+	if got, _ := ioutil.ReadAll(gz); string(got) != content {
+		t.Error("decompressed tarball does not match content")
+	}
+}
+
+// TestSaveLoadImages
+func TestSaveLoadImages(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	di.PullImage(ctx, "busybox")
+	defer di.RemoveImage(ctx, "busybox")
+
+	var buf bytes.Buffer
+	if err := di.SaveImages(ctx, []string{"busybox"}, &buf, TarballOptions{Gzip: true}); err != nil {
+		t.Logf("got error saving images: %s", err)
+		t.FailNow()
+	}
+
+	di.RemoveImage(ctx, "busybox")
+	loaded, err := di.LoadImages(ctx, &buf, TarballOptions{})
+	if err != nil {
+		t.Logf("got error loading images: %s", err)
+		t.FailNow()
+	}
+	if len(loaded) != 1 || loaded[0] != "busybox:latest" {
+		t.Errorf("got loaded images %v, want [busybox:latest]", loaded)
+	}
+}
+
+// TestExportImportContainer
+func TestExportImportContainer(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+	testContainer := map[string]string{"name": "test_container", "image": "busybox"}
+
+	conID, _ := di.NewContainer(ctx, testContainer)
+	defer di.RemoveContainer(ctx, conID)
+
+	var buf bytes.Buffer
+	if err := di.ExportContainer(ctx, conID, &buf, TarballOptions{}); err != nil {
+		t.Logf("got error exporting container: %s", err)
+		t.FailNow()
+	}
+
+	id, err := di.ImportImage(ctx, &buf, "dockland/imported:test", TarballOptions{})
+	if err != nil {
+		t.Logf("got error importing image: %s", err)
+		t.FailNow()
+	}
+	defer di.RemoveImage(ctx, id)
+
+	if _, err := di.ResolveImage("dockland/imported:test"); err != nil {
+		t.Errorf("got error finding imported image: %s", err)
+	}
+}
@@ -0,0 +1,178 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Endpoint is a container's attachment to a network.
+type Endpoint struct {
+	ContainerID string   `json:"containerId"`
+	Container   string   `json:"container"`
+	NetworkID   string   `json:"networkId"`
+	Network     string   `json:"network"`
+	IPv4Address string   `json:"ipv4Address,omitempty"`
+	IPv6Address string   `json:"ipv6Address,omitempty"`
+	MacAddress  string   `json:"macAddress,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// TopologyNetwork is a network and the containers attached to it.
+type TopologyNetwork struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Driver    string     `json:"driver"`
+	Subnets   []string   `json:"subnets,omitempty"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// TopologyContainer is a container and the networks it is attached to. A
+// container attached to more than one network is a bridge between them.
+type TopologyContainer struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Image     string     `json:"image"`
+	State     string     `json:"state"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Bridge reports whether the container is attached to more than one network.
+func (tc TopologyContainer) Bridge() bool {
+	return len(tc.Endpoints) > 1
+}
+
+// Topology joins the cached networks and containers, showing which
+// containers sit on which networks.
+type Topology struct {
+	Networks   []TopologyNetwork   `json:"networks"`
+	Containers []TopologyContainer `json:"containers"`
+}
+
+// Topology builds the network topology from the cached Networks and
+// Containers. Networks and containers are sorted by name.
+func (di *DockerInterface) Topology() Topology {
+	var topology Topology
+	networks := make(map[string]int, len(di.Networks))
+
+	for _, resource := range di.Networks {
+		tn := TopologyNetwork{ID: resource.ID, Name: resource.Name, Driver: resource.Driver}
+		for _, config := range resource.IPAM.Config {
+			tn.Subnets = append(tn.Subnets, config.Subnet)
+		}
+
+		networks[resource.ID] = len(topology.Networks)
+		topology.Networks = append(topology.Networks, tn)
+	}
+
+	for _, container := range di.Containers {
+		tc := TopologyContainer{ID: container.ID, Name: containerName(container),
+			Image: container.Image, State: container.State}
+
+		if container.NetworkSettings != nil {
+			for name, settings := range container.NetworkSettings.Networks {
+				if settings == nil {
+					continue
+				}
+
+				endpoint := Endpoint{
+					ContainerID: container.ID,
+					Container:   tc.Name,
+					NetworkID:   settings.NetworkID,
+					Network:     name,
+					IPv4Address: settings.IPAddress,
+					IPv6Address: settings.GlobalIPv6Address,
+					MacAddress:  settings.MacAddress,
+					Aliases:     settings.Aliases,
+				}
+				tc.Endpoints = append(tc.Endpoints, endpoint)
+
+				if i, ok := networks[settings.NetworkID]; ok {
+					topology.Networks[i].Endpoints = append(topology.Networks[i].Endpoints, endpoint)
+				}
+			}
+		}
+		sort.Slice(tc.Endpoints, func(i, j int) bool {
+			return tc.Endpoints[i].Network < tc.Endpoints[j].Network
+		})
+		topology.Containers = append(topology.Containers, tc)
+	}
+
+	sort.Slice(topology.Networks, func(i, j int) bool {
+		return topology.Networks[i].Name < topology.Networks[j].Name
+	})
+	for _, tn := range topology.Networks {
+		sort.Slice(tn.Endpoints, func(i, j int) bool {
+			return tn.Endpoints[i].Container < tn.Endpoints[j].Container
+		})
+	}
+	sort.Slice(topology.Containers, func(i, j int) bool {
+		return topology.Containers[i].Name < topology.Containers[j].Name
+	})
+	return topology
+}
+
+// Bridges returns the containers attached to more than one network.
+func (t Topology) Bridges() []TopologyContainer {
+	var bridges []TopologyContainer
+
+	for _, tc := range t.Containers {
+		if tc.Bridge() {
+			bridges = append(bridges, tc)
+		}
+	}
+	return bridges
+}
+
+// JSON returns the topology encoded as indented JSON.
+func (t Topology) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// DOT returns the topology as a Graphviz graph, with networks as ellipses,
+// containers as boxes, bridge containers in bold, and each edge labelled
+// with the container's address on the network. Endpoints on networks that
+// are not in the topology, or without a network ID because the container
+// has never started, are left out.
+func (t Topology) DOT() string {
+	var b strings.Builder
+	b.WriteString("graph topology {\n")
+
+	drawn := make(map[string]bool, len(t.Networks))
+	for _, tn := range t.Networks {
+		drawn[tn.ID] = true
+
+		label := tn.Name
+		if len(tn.Subnets) > 0 {
+			label += "\n" + strings.Join(tn.Subnets, "\n")
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, shape=ellipse];\n", "network:"+tn.ID, label)
+	}
+
+	for _, tc := range t.Containers {
+		style := ""
+		if tc.Bridge() {
+			style = ", style=bold"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, shape=box%s];\n", "container:"+tc.ID, tc.Name, style)
+	}
+
+	for _, tc := range t.Containers {
+		for _, endpoint := range tc.Endpoints {
+			if endpoint.NetworkID == "" || !drawn[endpoint.NetworkID] {
+				continue
+			}
+
+			address := endpoint.IPv4Address
+			if address == "" {
+				address = endpoint.IPv6Address
+			}
+			fmt.Fprintf(&b, "  %q -- %q [label=%q];\n",
+				"container:"+tc.ID, "network:"+endpoint.NetworkID, address)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
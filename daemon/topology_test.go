@@ -0,0 +1,130 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+)
+
+// Build an interface with a proxy bridging a frontend and backend network.
+func newTestTopologyInterface() *DockerInterface {
+	settings := func(endpoints map[string]*network.EndpointSettings) *types.SummaryNetworkSettings {
+		return &types.SummaryNetworkSettings{Networks: endpoints}
+	}
+
+	return &DockerInterface{
+		Networks: []types.NetworkResource{
+			{ID: "n1", Name: "frontend", Driver: "bridge",
+				IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.1.0.0/24"}}}},
+			{ID: "n2", Name: "backend", Driver: "bridge"},
+		},
+		Containers: []types.Container{
+			{ID: "c1", Names: []string{"/web"}, State: "running",
+				NetworkSettings: settings(map[string]*network.EndpointSettings{
+					"frontend": {NetworkID: "n1", IPAddress: "10.1.0.2", MacAddress: "02:42:0a:01:00:02"},
+				})},
+			{ID: "c2", Names: []string{"/proxy"}, State: "running",
+				NetworkSettings: settings(map[string]*network.EndpointSettings{
+					"frontend": {NetworkID: "n1", IPAddress: "10.1.0.3", Aliases: []string{"lb"}},
+					"backend":  {NetworkID: "n2", IPAddress: "10.2.0.2"},
+				})},
+			{ID: "c3", Names: []string{"/db"}, State: "exited"},
+			{ID: "c4", Names: []string{"/worker"}, State: "created",
+				NetworkSettings: settings(map[string]*network.EndpointSettings{
+					"jobs": {},
+				})},
+			{ID: "c5", Names: []string{"/agent"}, State: "running",
+				NetworkSettings: settings(map[string]*network.EndpointSettings{
+					"remote": {NetworkID: "n9", IPAddress: "10.9.0.2"},
+				})},
+		},
+	}
+}
+
+// TestTopology
+func TestTopology(t *testing.T) {
+	topology := newTestTopologyInterface().Topology()
+
+	if len(topology.Networks) != 2 || topology.Networks[0].Name != "backend" {
+		t.Logf("got networks %+v", topology.Networks)
+		t.FailNow()
+	}
+
+	frontend := topology.Networks[1]
+	if len(frontend.Endpoints) != 2 || frontend.Endpoints[0].Container != "proxy" ||
+		frontend.Endpoints[0].Aliases[0] != "lb" || frontend.Endpoints[1].IPv4Address != "10.1.0.2" {
+		t.Errorf("got frontend endpoints %+v", frontend.Endpoints)
+	}
+
+	bridges := topology.Bridges()
+	if len(bridges) != 1 || bridges[0].Name != "proxy" {
+		t.Errorf("got bridges %+v, want proxy", bridges)
+	}
+	if len(topology.Containers) != 5 || len(topology.Containers[1].Endpoints) != 0 {
+		t.Errorf("got containers %+v", topology.Containers)
+	}
+}
+
+// TestTopologyExport
+func TestTopologyExport(t *testing.T) {
+	topology := newTestTopologyInterface().Topology()
+
+	dot := topology.DOT()
+	if strings.Contains(dot, `"network:"`) || strings.Contains(dot, `"network:n9"`) {
+		t.Errorf("DOT output has edges to undrawn networks:\n%s", dot)
+	}
+	for _, want := range []string{
+		`"network:n1" [label="frontend\n10.1.0.0/24", shape=ellipse];`,
+		`"container:c2" [label="proxy", shape=box, style=bold];`,
+		`"container:c1" -- "network:n1" [label="10.1.0.2"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %s:\n%s", want, dot)
+		}
+	}
+
+	data, err := topology.JSON()
+	if err != nil {
+		t.Logf("got error encoding topology: %s", err)
+		t.FailNow()
+	}
+
+	var decoded Topology
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Containers) != 5 {
+		t.Errorf("got error %v decoding topology %s", err, data)
+	}
+}
+
+// TestInspectNetwork
+func TestInspectNetwork(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	netID, _ := di.NewNetwork(ctx, map[string]string{"name": "test_network"})
+	defer di.RemoveNetwork(ctx, netID)
+
+	conID, _ := di.NewContainer(ctx, map[string]string{"name": "test_container", "image": "nginx"})
+	defer di.RemoveContainer(ctx, conID)
+	di.StartContainer(ctx, conID)
+
+	if err := di.ConnectNetwork(ctx, netID, conID); err != nil {
+		t.Logf("got error connecting network: %s", err)
+		t.FailNow()
+	}
+
+	network, err := di.InspectNetwork(ctx, netID, true)
+	if err != nil {
+		t.Logf("got error inspecting network: %s", err)
+		t.FailNow()
+	}
+	if _, ok := network.Containers[conID]; network.Name != "test_network" || !ok {
+		t.Errorf("got network %s with containers %v", network.Name, network.Containers)
+	}
+	if _, err := di.InspectNetwork(ctx, "no_such_network", false); err == nil {
+		t.Error("expected error inspecting network")
+	}
+}
@@ -0,0 +1,144 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+// ImageUpdate compares a tagged local image with the digest its registry
+// currently serves for the tag.
+type ImageUpdate struct {
+	// ID is the local image ID.
+	ID string
+	// Ref is the tag that was checked, such as "nginx:latest".
+	Ref string
+	// LocalDigest is the first of LocalDigests.
+	LocalDigest string
+	// LocalDigests holds every digest the image was pulled by from Ref's
+	// repository, as a multi-platform image may have several.
+	LocalDigests []string
+	RemoteDigest string
+	// Stale is true if the registry serves an image for Ref matching none
+	// of LocalDigests.
+	Stale bool
+	// Err is set if the remote digest could not be resolved.
+	Err error
+}
+
+// localDigests returns the digests recorded when image was pulled from
+// tag's repository, or nil if image was not pulled from a registry.
+func localDigests(image types.ImageSummary, tag string) []string {
+	named, err := reference.ParseNormalizedNamed(tag)
+	if err != nil {
+		return nil
+	}
+
+	var digests []string
+	for _, repoDigest := range image.RepoDigests {
+		digested, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil || digested.Name() != named.Name() {
+			continue
+		}
+		if canonical, ok := digested.(reference.Canonical); ok {
+			digests = append(digests, canonical.Digest().String())
+		}
+	}
+	return digests
+}
+
+// remoteDigest returns the digest the registry serves for ref. The daemon
+// is asked first, falling back to querying the registry directly.
+func (di *DockerInterface) remoteDigest(ctx context.Context, ref string) (string, error) {
+	auth, err := di.registryAuth(imageRegistry(ref))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest for %s: %s", ref, err)
+	}
+
+	inspect, err := di.Client.DistributionInspect(ctx, ref, auth)
+	if err == nil {
+		return inspect.Descriptor.Digest.String(), nil
+	}
+
+	info, manifestErr := di.ImageManifest(ctx, ref)
+	if manifestErr != nil {
+		return "", fmt.Errorf("failed to resolve digest for %s: %s; %s", ref, err, manifestErr)
+	}
+	return info.Digest, nil
+}
+
+// CheckImageUpdates compares every cached image pulled from a registry
+// with the digest the registry currently serves for each of its tags.
+// Images built or loaded locally are skipped. Failures to reach a registry
+// are recorded in each ImageUpdate's Err rather than returned.
+func (di *DockerInterface) CheckImageUpdates(ctx context.Context) ([]ImageUpdate, error) {
+	var updates []ImageUpdate
+
+	for _, image := range di.Images {
+		for _, tag := range image.RepoTags {
+			if digests := localDigests(image, tag); len(digests) > 0 {
+				updates = append(updates, ImageUpdate{ID: image.ID, Ref: tag,
+					LocalDigest: digests[0], LocalDigests: digests})
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, DefaultBulkWorkers)
+
+	for i := range updates {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(update *ImageUpdate) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			update.RemoteDigest, update.Err = di.remoteDigest(ctx, update.Ref)
+			update.Stale = update.Err == nil &&
+				!containsString(update.LocalDigests, update.RemoteDigest)
+		}(&updates[i])
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check image updates: %s", err)
+	}
+	return updates, nil
+}
+
+// StaleContainers returns the cached running containers whose image is
+// marked stale in updates. Stopped containers are left out.
+func (di *DockerInterface) StaleContainers(updates []ImageUpdate) []types.Container {
+	stale := make(map[string]bool)
+
+	for _, update := range updates {
+		if update.Stale {
+			stale[update.ID] = true
+		}
+	}
+
+	return FilterContainers(di.Containers, func(container types.Container) bool {
+		return container.State == "running" && stale[container.ImageID]
+	})
+}
+
+// PullImageUpdates pulls the newer image for every stale ref in updates
+// and returns the pull error for each ref. Containers keep running their
+// old images until they are recreated.
+func (di *DockerInterface) PullImageUpdates(ctx context.Context,
+	updates []ImageUpdate, opts PullOptions) map[string]error {
+	results := make(map[string]error)
+
+	for _, update := range updates {
+		if update.Stale {
+			results[update.Ref] = di.PullImageWithOptions(ctx, update.Ref, opts)
+		}
+	}
+	return results
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// TestLocalDigests
+func TestLocalDigests(t *testing.T) {
+	image := types.ImageSummary{
+		RepoTags: []string{"nginx:latest", "registry.example.com/web:1"},
+		RepoDigests: []string{
+			"nginx@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			"registry.example.com/web@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+			"nginx@sha256:3333333333333333333333333333333333333333333333333333333333333333",
+		},
+	}
+
+	tables := map[string][]string{
+		"nginx:latest": {
+			"sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			"sha256:3333333333333333333333333333333333333333333333333333333333333333",
+		},
+		"registry.example.com/web:1": {
+			"sha256:2222222222222222222222222222222222222222222222222222222222222222",
+		},
+		"local/build:dev": nil,
+	}
+
+	for tag, want := range tables {
+		if got := localDigests(image, tag); !reflect.DeepEqual(got, want) {
+			t.Errorf("got digests %v for %s, want %v", got, tag, want)
+		}
+	}
+}
+
+// TestStaleContainers
+func TestStaleContainers(t *testing.T) {
+	di := &DockerInterface{Containers: []types.Container{
+		{ID: "a", ImageID: "sha256:old", State: "running"},
+		{ID: "b", ImageID: "sha256:new", State: "running"},
+		{ID: "c", ImageID: "sha256:old", State: "exited"},
+	}}
+
+	stale := di.StaleContainers([]ImageUpdate{
+		{ID: "sha256:old", Stale: true},
+		{ID: "sha256:new"},
+	})
+	if len(stale) != 1 || stale[0].ID != "a" {
+		t.Errorf("got %d stale containers, want only running a", len(stale))
+	}
+}
+
+// TestCheckImageUpdates
+func TestCheckImageUpdates(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	di.PullImage(ctx, "busybox")
+
+	updates, err := di.CheckImageUpdates(ctx)
+	if err != nil {
+		t.Logf("got error checking image updates: %s", err)
+		t.FailNow()
+	}
+
+	for _, update := range updates {
+		if update.Ref == "busybox:latest" && (update.Err != nil || update.Stale) {
+			t.Errorf("got update %+v for freshly pulled busybox", update)
+		}
+	}
+}
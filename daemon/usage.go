@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+)
+
+// CategoryUsage totals the disk usage of one kind of resource.
+type CategoryUsage struct {
+	Count int
+	// Active is the number of resources in use: images and volumes used by
+	// a container, running containers, and build cache in use.
+	Active int
+	Size   int64
+	// Reclaimable is the space that removing every unused resource of this
+	// kind would free.
+	Reclaimable int64
+}
+
+// ImageUsage is the disk usage of a single image.
+type ImageUsage struct {
+	ID   string
+	Name string
+	Size int64
+	// SharedSize is the size of layers shared with other images, or -1 if
+	// the daemon did not compute it.
+	SharedSize int64
+	UniqueSize int64
+	Containers int64
+}
+
+// ContainerUsage is the disk usage of a single container.
+type ContainerUsage struct {
+	ID    string
+	Name  string
+	Image string
+	State string
+	// SizeRw is the size of the container's writable layer.
+	SizeRw int64
+	// SizeRootFs is the size of every file in the container, including its
+	// image.
+	SizeRootFs int64
+}
+
+// VolumeUsage is the disk usage of a single volume. Size and RefCount are
+// -1 if the volume's driver does not report them.
+type VolumeUsage struct {
+	Name     string
+	Driver   string
+	Size     int64
+	RefCount int64
+}
+
+// BuildCacheUsage is the disk usage of a single build cache record.
+type BuildCacheUsage struct {
+	ID          string
+	Type        string
+	Description string
+	Size        int64
+	InUse       bool
+	Shared      bool
+}
+
+// DiskUsageReport breaks down the disk space used by the daemon.
+type DiskUsageReport struct {
+	Images     CategoryUsage
+	Containers CategoryUsage
+	Volumes    CategoryUsage
+	BuildCache CategoryUsage
+
+	ImageItems      []ImageUsage
+	ContainerItems  []ContainerUsage
+	VolumeItems     []VolumeUsage
+	BuildCacheItems []BuildCacheUsage
+}
+
+// Total returns the space used across every category.
+func (r DiskUsageReport) Total() int64 {
+	return r.Images.Size + r.Containers.Size + r.Volumes.Size + r.BuildCache.Size
+}
+
+// Reclaimable returns the space that could be freed across every category.
+func (r DiskUsageReport) Reclaimable() int64 {
+	return r.Images.Reclaimable + r.Containers.Reclaimable +
+		r.Volumes.Reclaimable + r.BuildCache.Reclaimable
+}
+
+// newDiskUsageReport converts the daemon's disk usage into a report,
+// deriving reclaimable space the same way as docker system df.
+func newDiskUsageReport(usage types.DiskUsage) DiskUsageReport {
+	var report DiskUsageReport
+	var imagesUsed int64
+
+	report.Images.Size = usage.LayersSize
+	for _, image := range usage.Images {
+		item := ImageUsage{
+			ID:         image.ID,
+			Name:       imageName(*image),
+			Size:       image.Size,
+			SharedSize: image.SharedSize,
+			UniqueSize: image.Size,
+			Containers: image.Containers,
+		}
+		if image.SharedSize > 0 {
+			item.UniqueSize -= image.SharedSize
+		}
+
+		report.Images.Count++
+		if image.Containers > 0 {
+			report.Images.Active++
+			if image.SharedSize != -1 {
+				imagesUsed += item.UniqueSize
+			}
+		}
+		report.ImageItems = append(report.ImageItems, item)
+	}
+	report.Images.Reclaimable = report.Images.Size - imagesUsed
+
+	for _, container := range usage.Containers {
+		item := ContainerUsage{
+			ID:         container.ID,
+			Name:       containerName(*container),
+			Image:      container.Image,
+			State:      container.State,
+			SizeRw:     container.SizeRw,
+			SizeRootFs: container.SizeRootFs,
+		}
+
+		report.Containers.Count++
+		report.Containers.Size += container.SizeRw
+		if container.State == "running" || container.State == "paused" ||
+			container.State == "restarting" {
+			report.Containers.Active++
+		} else {
+			report.Containers.Reclaimable += container.SizeRw
+		}
+		report.ContainerItems = append(report.ContainerItems, item)
+	}
+
+	for _, volume := range usage.Volumes {
+		item := VolumeUsage{Name: volume.Name, Driver: volume.Driver, Size: -1, RefCount: -1}
+		if volume.UsageData != nil {
+			item.Size, item.RefCount = volume.UsageData.Size, volume.UsageData.RefCount
+		}
+
+		report.Volumes.Count++
+		if item.RefCount > 0 {
+			report.Volumes.Active++
+		}
+		if item.Size > 0 {
+			report.Volumes.Size += item.Size
+			if item.RefCount == 0 {
+				report.Volumes.Reclaimable += item.Size
+			}
+		}
+		report.VolumeItems = append(report.VolumeItems, item)
+	}
+
+	for _, cache := range usage.BuildCache {
+		item := BuildCacheUsage{
+			ID:          cache.ID,
+			Type:        cache.Type,
+			Description: cache.Description,
+			Size:        cache.Size,
+			InUse:       cache.InUse,
+			Shared:      cache.Shared,
+		}
+
+		report.BuildCache.Count++
+		if cache.InUse {
+			report.BuildCache.Active++
+		}
+		if !cache.Shared {
+			report.BuildCache.Size += cache.Size
+			if !cache.InUse {
+				report.BuildCache.Reclaimable += cache.Size
+			}
+		}
+		report.BuildCacheItems = append(report.BuildCacheItems, item)
+	}
+	return report
+}
+
+// DiskUsage returns the disk space used by images, containers, volumes,
+// and the build cache, with the space reclaimable from each.
+func (di *DockerInterface) DiskUsage(ctx context.Context) (DiskUsageReport, error) {
+	usage, err := di.Client.DiskUsage(ctx)
+	if err != nil {
+		return DiskUsageReport{}, fmt.Errorf("failed to fetch disk usage: %s", err)
+	}
+	return newDiskUsageReport(usage), nil
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// TestNewDiskUsageReport
+func TestNewDiskUsageReport(t *testing.T) {
+	usage := types.DiskUsage{
+		LayersSize: 1000,
+		Images: []*types.ImageSummary{
+			{ID: "used", RepoTags: []string{"web:latest"}, Size: 600, SharedSize: 200, Containers: 1},
+			{ID: "unused", Size: 400, SharedSize: 200},
+		},
+		Containers: []*types.Container{
+			{ID: "running", Names: []string{"/web"}, State: "running", SizeRw: 10},
+			{ID: "exited", State: "exited", SizeRw: 30},
+		},
+		Volumes: []*types.Volume{
+			{Name: "used", UsageData: &types.VolumeUsageData{Size: 100, RefCount: 1}},
+			{Name: "orphan", UsageData: &types.VolumeUsageData{Size: 50, RefCount: 0}},
+			{Name: "remote", UsageData: &types.VolumeUsageData{Size: -1, RefCount: -1}},
+		},
+		BuildCache: []*types.BuildCache{
+			{ID: "a", Size: 70, InUse: true},
+			{ID: "b", Size: 20},
+			{ID: "c", Size: 5, Shared: true},
+		},
+	}
+
+	report := newDiskUsageReport(usage)
+	tables := []struct {
+		name string
+		got  CategoryUsage
+		want CategoryUsage
+	}{
+		{"images", report.Images, CategoryUsage{2, 1, 1000, 600}},
+		{"containers", report.Containers, CategoryUsage{2, 1, 40, 30}},
+		{"volumes", report.Volumes, CategoryUsage{3, 1, 150, 50}},
+		{"build cache", report.BuildCache, CategoryUsage{3, 1, 90, 20}},
+	}
+
+	for _, table := range tables {
+		if table.got != table.want {
+			t.Errorf("got %s usage %+v, want %+v", table.name, table.got, table.want)
+		}
+	}
+
+	if report.ImageItems[0].UniqueSize != 400 || report.ImageItems[0].Name != "web:latest" {
+		t.Errorf("got image usage %+v, want web:latest with 400 unique bytes", report.ImageItems[0])
+	}
+	if report.Total() != 1280 || report.Reclaimable() != 700 {
+		t.Errorf("got %d total and %d reclaimable, want 1280 and 700",
+			report.Total(), report.Reclaimable())
+	}
+}
+
+// TestDiskUsage
+func TestDiskUsage(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	report, err := di.DiskUsage(ctx)
+	if err != nil {
+		t.Logf("got error fetching disk usage: %s", err)
+		t.FailNow()
+	}
+	if report.Images.Count != len(report.ImageItems) {
+		t.Errorf("got %d images counted, want %d", report.Images.Count, len(report.ImageItems))
+	}
+	if report.Reclaimable() > report.Total() {
+		t.Errorf("got %d reclaimable bytes, more than %d total", report.Reclaimable(), report.Total())
+	}
+}
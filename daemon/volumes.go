@@ -5,47 +5,71 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/volume"
 )
 
-// newVolumeCreateBody takes a map of options and creates the necessary
+// VolumeSpec holds the typed configuration of a new volume.
+type VolumeSpec struct {
+	Name       string
+	Driver     string
+	Labels     map[string]string
+	DriverOpts map[string]string
+}
+
+// newVolumeSpec takes a map of options and parses it into a volume spec.
+// Labels and driver options are comma-separated key=value lists, and
+// entries containing commas may be quoted as in ParseKeyValues.
+func newVolumeSpec(opts map[string]string) (VolumeSpec, error) {
+	spec := VolumeSpec{Name: opts["name"], Driver: opts["driver"]}
+
+	labels, err := ParseKeyValues("label", opts["labels"])
+	if err != nil {
+		return spec, err
+	}
+
+	driverOpts, err := ParseKeyValues("option", opts["options"])
+	if err != nil {
+		return spec, err
+	}
+
+	spec.Labels, spec.DriverOpts = labels, driverOpts
+	return spec, nil
+}
+
+// newVolumeCreateBody takes a volume spec and creates the necessary
 // configuration struct to create a new volume.
-func newVolumeCreateBody(opts map[string]string) *volume.VolumeCreateBody {
+func newVolumeCreateBody(spec VolumeSpec) *volume.VolumeCreateBody {
 	config := &volume.VolumeCreateBody{
-		Name:       opts["name"],
-		Driver:     opts["driver"],
+		Name:       spec.Name,
+		Driver:     spec.Driver,
 		Labels:     make(map[string]string),
 		DriverOpts: make(map[string]string),
 	}
 
-	for _, label := range strings.Split(opts["labels"], ",") {
-		optValue := strings.SplitN(label, "=", 2)
-
-		if len(optValue) > 1 {
-			config.Labels[strings.TrimSpace(optValue[0])] = optValue[1]
-		}
+	for key, value := range spec.Labels {
+		config.Labels[key] = value
 	}
-
-	for _, driverOpt := range strings.Split(opts["options"], ",") {
-		optValue := strings.SplitN(driverOpt, "=", 2)
-
-		if len(optValue) > 1 {
-			config.DriverOpts[strings.TrimSpace(optValue[0])] = optValue[1]
-		}
+	for key, value := range spec.DriverOpts {
+		config.DriverOpts[key] = value
 	}
-
 	return config
 }
 
 // NewVolume creates a new volume with the provided options and returns the
-// volume's name.
+// volume's name. Malformed labels or options are reported as *OptionError.
 func (di *DockerInterface) NewVolume(ctx context.Context, opts map[string]string) (string, error) {
-	config := *newVolumeCreateBody(opts)
-
-	response, err := di.Client.VolumeCreate(
-		ctx,
-		config)
+	spec, err := newVolumeSpec(opts)
+	if err != nil {
+		return "", err
+	}
+	return di.CreateVolume(ctx, spec)
+}
 
+// CreateVolume creates a new volume from a typed spec and returns the
+// volume's name.
+func (di *DockerInterface) CreateVolume(ctx context.Context, spec VolumeSpec) (string, error) {
+	response, err := di.Client.VolumeCreate(ctx, *newVolumeCreateBody(spec))
 	if err != nil {
 		return "", fmt.Errorf("failed to create volume: %s", err)
 	}
@@ -53,7 +77,89 @@ func (di *DockerInterface) NewVolume(ctx context.Context, opts map[string]string
 	return response.Name, di.RefreshVolumes(ctx)
 }
 
-// RemoveVolume removes a volume.
+// VolumeInUseError is returned by SafeRemoveVolume when containers still
+// mount the volume.
+type VolumeInUseError struct {
+	Volume     string
+	Containers []string
+}
+
+// Error is called when a volume cannot be removed because it is in use.
+func (v *VolumeInUseError) Error() string {
+	return fmt.Sprintf("volume %s is in use by %s", v.Volume, strings.Join(v.Containers, ", "))
+}
+
+// InspectVolume returns the JSON file generated by the Docker Engine for the
+// given volume, with its size and reference count filled in for drivers
+// that report them. If the daemon cannot report disk usage, both are set to
+// -1, as the daemon does for sizes it does not know.
+func (di *DockerInterface) InspectVolume(ctx context.Context, name string) (types.Volume, error) {
+	response, err := di.Client.VolumeInspect(ctx, name)
+	if err != nil {
+		return types.Volume{}, fmt.Errorf("failed to fetch volume: %s", err)
+	}
+
+	if response.UsageData != nil {
+		return response, nil
+	}
+
+	usage, err := di.DiskUsage(ctx)
+	if err != nil {
+		response.UsageData = &types.VolumeUsageData{Size: -1, RefCount: -1}
+		return response, nil
+	}
+
+	for _, item := range usage.VolumeItems {
+		if item.Name == response.Name {
+			response.UsageData = &types.VolumeUsageData{Size: item.Size, RefCount: item.RefCount}
+		}
+	}
+	return response, nil
+}
+
+// VolumeUsers returns a map from each volume name to the cached containers,
+// running or stopped, that mount it. Volumes without users are omitted.
+func (di *DockerInterface) VolumeUsers() map[string][]types.Container {
+	users := make(map[string][]types.Container)
+
+	for _, container := range di.Containers {
+		for _, mount := range container.Mounts {
+			if mount.Type == "volume" && mount.Name != "" {
+				users[mount.Name] = append(users[mount.Name], container)
+			}
+		}
+	}
+	return users
+}
+
+// VolumeContainers returns the cached containers that mount the volume.
+func (di *DockerInterface) VolumeContainers(name string) []types.Container {
+	return di.VolumeUsers()[name]
+}
+
+// SafeRemoveVolume removes a volume only if no container, running or
+// stopped, mounts it. Otherwise it returns a *VolumeInUseError naming the
+// containers.
+func (di *DockerInterface) SafeRemoveVolume(ctx context.Context, name string) error {
+	if err := di.RefreshContainers(ctx); err != nil {
+		return err
+	}
+
+	if users := di.VolumeContainers(name); len(users) > 0 {
+		names := make([]string, 0, len(users))
+		for _, container := range users {
+			names = append(names, containerName(container))
+		}
+		return &VolumeInUseError{name, names}
+	}
+
+	if err := di.Client.VolumeRemove(ctx, name, false); err != nil {
+		return fmt.Errorf("failed to remove volume: %s", err)
+	}
+	return di.RefreshVolumes(ctx)
+}
+
+// RemoveVolume removes a volume, even if it is in use.
 func (di *DockerInterface) RemoveVolume(ctx context.Context, id string) error {
 	if err := di.Client.VolumeRemove(ctx, id, true); err != nil {
 		return fmt.Errorf("failed to remove volume: %s", err)
@@ -49,6 +49,11 @@ func TestNewVolume(t *testing.T) {
 			volumeCompare{"test3", "local", map[string]string{},
 				map[string]string{"type": "tmpfs", "device": "tmpfs"}},
 		},
+		{
+			map[string]string{"name": "test4", "options": `type=tmpfs,device=tmpfs,"o=size=1m,uid=1000"`},
+			volumeCompare{"test4", "local", map[string]string{},
+				map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=1m,uid=1000"}},
+		},
 	}
 
 	ctx := context.TODO()
@@ -81,6 +86,28 @@ func TestNewVolume(t *testing.T) {
 	}
 }
 
+// TestNewVolumeSpec
+func TestNewVolumeSpec(t *testing.T) {
+	opts := map[string]string{"name": "nfs", "driver": "local",
+		"labels": "team=payments", "options": `type=nfs,"o=addr=10.0.0.1,rw",device=:/export`}
+
+	spec, err := newVolumeSpec(opts)
+	if err != nil {
+		t.Logf("got error parsing volume options: %s", err)
+		t.FailNow()
+	}
+
+	want := VolumeSpec{"nfs", "local", map[string]string{"team": "payments"},
+		map[string]string{"type": "nfs", "o": "addr=10.0.0.1,rw", "device": ":/export"}}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("got spec %+v, want %+v", spec, want)
+	}
+
+	if _, err := newVolumeSpec(map[string]string{"labels": "team"}); err == nil {
+		t.Error("expected error parsing label without value")
+	}
+}
+
 // TestRemoveVolume
 func TestRemoveVolume(t *testing.T) {
 	ctx := context.TODO()
@@ -93,3 +120,83 @@ func TestRemoveVolume(t *testing.T) {
 		t.Errorf("got error removing volume: %s", name)
 	}
 }
+
+// TestInspectVolume
+func TestInspectVolume(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	testVolume := map[string]string{"name": "inspect_volume", "labels": "case=inspect"}
+	name, _ := di.NewVolume(ctx, testVolume)
+	defer di.RemoveVolume(ctx, name)
+
+	volume, err := di.InspectVolume(ctx, name)
+	if err != nil {
+		t.Logf("got error inspecting volume: %s", err)
+		t.FailNow()
+	}
+	if volume.Mountpoint == "" || volume.Labels["case"] != "inspect" {
+		t.Errorf("got mountpoint %q and labels %v", volume.Mountpoint, volume.Labels)
+	}
+	if volume.UsageData == nil || volume.UsageData.RefCount != 0 {
+		t.Errorf("got usage data %+v, want unreferenced volume", volume.UsageData)
+	}
+	if _, err := di.InspectVolume(ctx, "no_such_volume"); err == nil {
+		t.Error("expected error inspecting volume")
+	}
+}
+
+// TestInspectVolumeUsageUnknown
+func TestInspectVolumeUsageUnknown(t *testing.T) {
+	di := newTestEngineInterface(t, nil, nil)
+
+	volume, err := di.InspectVolume(context.TODO(), "data")
+	if err != nil {
+		t.Logf("got error inspecting volume: %s", err)
+		t.FailNow()
+	}
+	if volume.UsageData == nil || volume.UsageData.Size != -1 || volume.UsageData.RefCount != -1 {
+		t.Errorf("got usage data %+v, want unknown size", volume.UsageData)
+	}
+}
+
+// TestVolumeUsers
+func TestVolumeUsers(t *testing.T) {
+	di := &DockerInterface{Containers: []types.Container{
+		{ID: "a", Names: []string{"/db"}, Mounts: []types.MountPoint{
+			{Type: "volume", Name: "pgdata"}, {Type: "bind", Source: "/etc"}}},
+		{ID: "b", Names: []string{"/backup"}, Mounts: []types.MountPoint{
+			{Type: "volume", Name: "pgdata"}}},
+	}}
+
+	users := di.VolumeUsers()
+	if len(users) != 1 || len(users["pgdata"]) != 2 {
+		t.Errorf("got users %v, want two containers for pgdata", users)
+	}
+	if len(di.VolumeContainers("unused")) != 0 {
+		t.Error("got users for unused volume")
+	}
+}
+
+// TestSafeRemoveVolume
+func TestSafeRemoveVolume(t *testing.T) {
+	ctx := context.TODO()
+	di, _ := NewInterface(ctx)
+
+	name, _ := di.NewVolume(ctx, map[string]string{"name": "safe_volume"})
+	defer di.RemoveVolume(ctx, name)
+
+	conID, _ := di.NewContainer(ctx, map[string]string{"name": "test_container",
+		"image": "nginx", "volumes": name + ":/data"})
+	defer di.RemoveContainer(ctx, conID)
+
+	err := di.SafeRemoveVolume(ctx, name)
+	if inUse, ok := err.(*VolumeInUseError); !ok || inUse.Containers[0] != "test_container" {
+		t.Errorf("got error %v, want volume in use by test_container", err)
+	}
+
+	di.RemoveContainer(ctx, conID)
+	if err := di.SafeRemoveVolume(ctx, name); err != nil {
+		t.Errorf("got error removing unused volume: %s", err)
+	}
+}